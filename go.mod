@@ -1,3 +1,7 @@
 module github.com/charlesdunbar/lox-go
 
 go 1.18
+
+require github.com/chzyer/readline v1.5.1
+
+require golang.org/x/sys v0.7.0 // indirect
@@ -0,0 +1,211 @@
+package lox
+
+/*
+analyzer looks for code that's valid but probably a mistake: statements that
+can never run, functions that return a value on only some paths and if
+statements whose condition is a constant. It runs after the Resolver and
+everything it finds is a warning, so programs still run.
+*/
+type analyzer struct {
+	diagnostics *Diagnostics
+	// The return statements in the function being analysed
+	returns []*Return
+	// Set when the innermost loop has a break out of it
+	loopBreaks bool
+}
+
+func analyze(statements []Stmt, diagnostics *Diagnostics) {
+	a := analyzer{diagnostics: diagnostics}
+	a.block(statements)
+}
+
+// analyzeExpression checks the functions in an expression evaluated on its own, like one typed at the prompt
+func analyzeExpression(expr Expr, diagnostics *Diagnostics) {
+	a := analyzer{diagnostics: diagnostics}
+	a.expr(expr)
+}
+
+// exit is how a statement can leave the code after it unreachable
+type exit struct {
+	// Set when the statement always jumps away instead of carrying on to the next one
+	always bool
+	// The statement that does the jumping, which warnings point at
+	at Token
+	// Set for a loop that never stops, which has no keyword to point at
+	endless bool
+}
+
+/*
+block analyses statements in order. It reports whether they always jump away
+before reaching the end, warning about any statements that come after the one
+that does it.
+*/
+func (a *analyzer) block(stmts []Stmt) exit {
+	var blockExit exit
+	for n, stmt := range stmts {
+		if stmtExit := a.stmt(stmt); stmtExit.always && !blockExit.always {
+			blockExit = stmtExit
+			if n < len(stmts)-1 && !stmtExit.endless {
+				a.diagnostics.warning(stmtExit.at, "Code after this statement is unreachable.")
+			}
+		}
+	}
+	return blockExit
+}
+
+// stmt analyses a statement, reporting whether it always jumps away and where
+func (a *analyzer) stmt(stmt Stmt) exit {
+	switch t := stmt.(type) {
+	case *Block:
+		return a.block(t.statements)
+	case *Break:
+		a.loopBreaks = true
+		return exit{always: true, at: t.keyword}
+	case *Class:
+		for n := range t.methods {
+			a.function(t.methods[n].name, t.methods[n].body)
+		}
+	case *Continue:
+		return exit{always: true, at: t.keyword}
+	case *Expression:
+		a.expr(t.expression)
+	case *Function:
+		a.function(t.name, t.body)
+	case *If:
+		a.expr(t.condition)
+		a.constantCondition(t)
+		thenExit := a.stmt(t.thenBranch)
+		if t.elseBranch == nil {
+			return exit{}
+		}
+		elseExit := a.stmt(t.elseBranch)
+		return exit{always: thenExit.always && elseExit.always, at: t.keyword}
+	case *Print:
+		a.expr(t.expression)
+	case *Return:
+		a.returns = append(a.returns, t)
+		if t.value != nil {
+			a.expr(t.value)
+		}
+		return exit{always: true, at: t.keyword}
+	case *Throw:
+		a.expr(t.value)
+		return exit{always: true, at: t.keyword}
+	case *Try:
+		tryExit := a.block(t.tryBranch)
+		catchExits := true
+		if t.catchName.lexeme != "" {
+			catchExits = a.block(t.catchBranch).always
+		}
+		finallyExit := a.block(t.finallyBranch)
+		return exit{always: finallyExit.always || tryExit.always && catchExits, at: t.keyword}
+	case *Var:
+		if t.initializer != nil {
+			a.expr(t.initializer)
+		}
+	case *While:
+		enclosingBreaks := a.loopBreaks
+		a.loopBreaks = false
+		a.expr(t.condition)
+		a.stmt(t.body)
+		if t.increment != nil {
+			a.expr(t.increment)
+		}
+		breaks := a.loopBreaks
+		a.loopBreaks = enclosingBreaks
+		// A loop that can't stop never lets the code after it run
+		if value, ok := constant(t.condition); ok && isTruthy(value) && !breaks {
+			return exit{always: true, endless: true}
+		}
+	}
+	return exit{}
+}
+
+// expr looks inside an expression for function bodies to analyse
+func (a *analyzer) expr(expr Expr) {
+	switch t := expr.(type) {
+	case *Assign:
+		a.expr(t.value)
+	case *Binary:
+		a.expr(t.left)
+		a.expr(t.right)
+	case *Call:
+		a.expr(t.callee)
+		for _, arg := range t.arguments {
+			a.expr(arg)
+		}
+	case *Get:
+		a.expr(t.object)
+	case *Grouping:
+		a.expr(t.expression)
+	case *Index:
+		a.expr(t.object)
+		a.expr(t.index)
+	case *Lambda:
+		a.function(t.keyword, t.body)
+	case *List:
+		for _, element := range t.elements {
+			a.expr(element)
+		}
+	case *Logical:
+		a.expr(t.left)
+		a.expr(t.right)
+	case *Map:
+		for n := range t.keys {
+			a.expr(t.keys[n])
+			a.expr(t.values[n])
+		}
+	case *Set:
+		a.expr(t.object)
+		a.expr(t.value)
+	case *SetIndex:
+		a.expr(t.object)
+		a.expr(t.index)
+		a.expr(t.value)
+	case *Unary:
+		a.expr(t.right)
+	}
+}
+
+// function analyses a function's body, warning if only some of its paths return a value
+func (a *analyzer) function(name Token, body []Stmt) {
+	enclosingReturns, enclosingBreaks := a.returns, a.loopBreaks
+	a.returns, a.loopBreaks = nil, false
+
+	returnsValue, returnsNothing := false, !a.block(body).always
+	for _, r := range a.returns {
+		if r.value != nil {
+			returnsValue = true
+		} else {
+			returnsNothing = true
+		}
+	}
+	if returnsValue && returnsNothing {
+		a.diagnostics.warning(name, "Function returns a value on some paths but not others.")
+	}
+
+	a.returns, a.loopBreaks = enclosingReturns, enclosingBreaks
+}
+
+func (a *analyzer) constantCondition(stmt *If) {
+	value, ok := constant(stmt.condition)
+	if !ok {
+		return
+	}
+	if isTruthy(value) {
+		a.diagnostics.warning(stmt.keyword, "Condition is always true.")
+	} else {
+		a.diagnostics.warning(stmt.keyword, "Condition is always false.")
+	}
+}
+
+// constant returns the value of expr if it's a literal, possibly in parentheses
+func constant(expr Expr) (any, bool) {
+	switch t := expr.(type) {
+	case *Literal:
+		return t.value, true
+	case *Grouping:
+		return constant(t.expression)
+	}
+	return nil, false
+}
@@ -0,0 +1,89 @@
+package lox
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzerWarnings(t *testing.T) {
+	tests := []struct {
+		source   string
+		warnings []string
+	}{
+		{"fun f() { return 1; print 2; }", []string{"[line 1] Warning at 'return': Code after this statement is unreachable."}},
+		{"while (false) { break; print 1; }", []string{"[line 1] Warning at 'break': Code after this statement is unreachable."}},
+		{"for (var i = 0; i < 1; i = i + 1) { continue; print i; }", []string{"[line 1] Warning at 'continue': Code after this statement is unreachable."}},
+		{"fun f() {\n  throw 1;\n  print 2;\n  print 3;\n}", []string{"[line 2] Warning at 'throw': Code after this statement is unreachable."}},
+		{"fun f(x) { if (x) return 1; else return 2; print 3; }", []string{"[line 1] Warning at 'if': Code after this statement is unreachable."}},
+		{"fun f(x) { { return x; } print x; }", []string{"[line 1] Warning at 'return': Code after this statement is unreachable."}},
+		// The return is the last statement in its own block
+		{"fun f(x) { if (x) { return 1; } print x; }", []string{"[line 1] Warning at 'f': Function returns a value on some paths but not others."}},
+		{"fun f(x) { if (x) return 1; return; }", []string{"[line 1] Warning at 'f': Function returns a value on some paths but not others."}},
+		{"fun f(x) { if (x) return 1; else return 2; }", nil},
+		{"fun f(x) { if (x) return 1; throw \"no\"; }", nil},
+		{"fun f(x) { try { return x(); } catch (e) { print e; } }", []string{"[line 1] Warning at 'f': Function returns a value on some paths but not others."}},
+		{"fun f(x) { try { return x(); } catch (e) { return e; } }", nil},
+		{"fun f(x) { try { x(); } finally { return 1; } }", nil},
+		// Loops that never finish never fall off the end
+		{"fun f(x) { while (true) { if (x()) return 1; } }", nil},
+		{"fun f(x) { for (;;) { if (x()) return 1; } }", nil},
+		{"fun f(x) { while (true) { if (x()) break; return 1; } }", []string{"[line 1] Warning at 'f': Function returns a value on some paths but not others."}},
+		{"var f = fun (x) { if (x) return 1; };", []string{"[line 1] Warning at 'fun': Function returns a value on some paths but not others."}},
+		{"class A { m(x) { if (x) return this; } }", []string{"[line 1] Warning at 'm': Function returns a value on some paths but not others."}},
+		// Each function is judged on its own returns
+		{"fun f() { fun g() { return 1; } g(); }", nil},
+		{"fun f() { print 1; }", nil},
+		{"if (true) print 1;", []string{"[line 1] Warning at 'if': Condition is always true."}},
+		{"if ((nil)) print 1; else print 2;", []string{"[line 1] Warning at 'if': Condition is always false."}},
+		{"if (0) print 1;", []string{"[line 1] Warning at 'if': Condition is always true."}},
+		{"var a = true; if (a) print 1; while (true) break;", nil},
+		// An endless loop has nothing to point at, so only its function is judged
+		{"fun f() { while (true) {} print 1; }", nil},
+		// Warnings come out in the order of the code, whichever pass found them
+		{"fun f() {\n  return 1;\n  var unused = 2;\n}", []string{
+			"[line 2] Warning at 'return': Code after this statement is unreachable.",
+			"[line 3] Warning at 'unused': Local variable is never used.",
+		}},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if err := New(WithStdout(&stdout), WithStderr(&stderr)).Run(test.source); err != nil {
+			t.Errorf("%s: warnings should not stop the program, got %v", test.source, err)
+			continue
+		}
+		var warnings []string
+		for _, line := range splitLines(stderr.String()) {
+			if reportedWarningPattern.MatchString(line) {
+				warnings = append(warnings, line)
+			}
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: warnings were\n%q\nexpected\n%q", test.source, warnings, test.warnings)
+		}
+	}
+}
+
+// Programs with errors aren't analysed, so warnings don't bury the errors
+func TestAnalyzerSkipsProgramsWithErrors(t *testing.T) {
+	var stderr bytes.Buffer
+	New(WithStderr(&stderr)).Run("fun f() { return 1; print 2; }\nreturn;")
+
+	for _, line := range splitLines(stderr.String()) {
+		if reportedWarningPattern.MatchString(line) {
+			t.Errorf("got warning %q from a program that doesn't resolve", line)
+		}
+	}
+}
+
+func TestEvalAnalyzesFunctions(t *testing.T) {
+	var stderr bytes.Buffer
+	l := New(WithStderr(&stderr))
+	if _, err := l.Eval("fun (x) { if (x) return 1; }"); err != nil {
+		t.Fatalf("Eval() returned %v", err)
+	}
+	expected := "[line 1] Warning at 'fun': Function returns a value on some paths but not others."
+	if got := splitLines(stderr.String()); len(got) == 0 || got[0] != expected {
+		t.Errorf("stderr was %q, expected it to start with %q", stderr.String(), expected)
+	}
+}
@@ -0,0 +1,231 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Every node prints as an S-expression, so a whole program can be dumped with
+--ast. Statements nest their bodies on the same line:
+
+	(fun add (a b) (return (+ a b)))
+*/
+
+func (a Assign) String() string {
+	return parenthesize("=", &Variable{a.name}, a.value)
+}
+
+func (b Binary) String() string {
+	return parenthesize(b.operator.lexeme, b.left, b.right)
+}
+
+func (c Call) String() string {
+	return parenthesize("call", append([]Expr{c.callee}, c.arguments...)...)
+}
+
+func (g Get) String() string {
+	return parenthesize(".", g.object, &Variable{g.name})
+}
+
+func (g Grouping) String() string {
+	return parenthesize("group", g.expression)
+}
+
+func (i Index) String() string {
+	return parenthesize("[]", i.object, i.index)
+}
+
+func (l Lambda) String() string {
+	return "(fun " + params(l.params) + statements(l.body) + ")"
+}
+
+func (l List) String() string {
+	return parenthesize("list", l.elements...)
+}
+
+func (l Literal) String() string {
+	switch v := l.value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	}
+	return exactString(l.value)
+}
+
+// exactString is stringify with every digit of numbers kept, for tools showing what's really in the code
+func exactString(value any) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return stringify(value)
+}
+
+func (l Logical) String() string {
+	return parenthesize(l.operator.lexeme, l.left, l.right)
+}
+
+func (m Map) String() string {
+	var entries []Expr
+	for n := range m.keys {
+		entries = append(entries, m.keys[n], m.values[n])
+	}
+	return parenthesize("map", entries...)
+}
+
+func (s Set) String() string {
+	return parenthesize("=", &Get{s.object, s.name}, s.value)
+}
+
+func (s SetIndex) String() string {
+	return parenthesize("=", &Index{s.object, s.bracket, s.index}, s.value)
+}
+
+func (s Super) String() string {
+	return "(super " + s.method.lexeme + ")"
+}
+
+func (t This) String() string {
+	return "this"
+}
+
+func (u Unary) String() string {
+	return parenthesize(u.operator.lexeme, u.right)
+}
+
+func (v Variable) String() string {
+	return v.name.lexeme
+}
+
+func (b Block) String() string {
+	return "(block" + statements(b.statements) + ")"
+}
+
+func (b Break) String() string {
+	return "(break)"
+}
+
+func (c Class) String() string {
+	s := "(class " + c.name.lexeme
+	if c.superclass != (Variable{}) {
+		s += " < " + c.superclass.name.lexeme
+	}
+	for _, method := range c.methods {
+		s += " " + method.String()
+	}
+	return s + ")"
+}
+
+func (c Continue) String() string {
+	return "(continue)"
+}
+
+func (e Expression) String() string {
+	return parenthesize(";", e.expression)
+}
+
+func (f Function) String() string {
+	return "(fun " + f.name.lexeme + " " + params(f.params) + statements(f.body) + ")"
+}
+
+func (i If) String() string {
+	s := "(if " + fmt.Sprint(i.condition) + " " + fmt.Sprint(i.thenBranch)
+	if i.elseBranch != nil {
+		s += " " + fmt.Sprint(i.elseBranch)
+	}
+	return s + ")"
+}
+
+func (v Var) String() string {
+	if v.initializer == nil {
+		return "(var " + v.name.lexeme + ")"
+	}
+	return parenthesize("var "+v.name.lexeme, v.initializer)
+}
+
+func (p Print) String() string {
+	return parenthesize("print", p.expression)
+}
+
+func (r Return) String() string {
+	if r.value == nil {
+		return "(return)"
+	}
+	return parenthesize("return", r.value)
+}
+
+func (t Throw) String() string {
+	return parenthesize("throw", t.value)
+}
+
+func (t Try) String() string {
+	s := "(try (block" + statements(t.tryBranch) + ")"
+	if t.catchName.lexeme != "" {
+		s += " (catch " + t.catchName.lexeme + statements(t.catchBranch) + ")"
+	}
+	if len(t.finallyBranch) > 0 {
+		s += " (finally" + statements(t.finallyBranch) + ")"
+	}
+	return s + ")"
+}
+
+func (w While) String() string {
+	s := "(while " + fmt.Sprint(w.condition) + " " + fmt.Sprint(w.body)
+	// The increment of a desugared for loop
+	if w.increment != nil {
+		s += " " + fmt.Sprint(w.increment)
+	}
+	return s + ")"
+}
+
+func parenthesize(name string, exprs ...Expr) string {
+	s := ""
+	s += "(" + name
+	for _, e := range exprs {
+		s += " "
+		s += fmt.Sprint(e)
+	}
+	s += ")"
+
+	return s
+}
+
+// params prints a parameter list like (a b c)
+func params(tokens []Token) string {
+	names := make([]string, len(tokens))
+	for n, token := range tokens {
+		names[n] = token.lexeme
+	}
+	return "(" + strings.Join(names, " ") + ")"
+}
+
+// statements prints a body, each statement after a space
+func statements(stmts []Stmt) string {
+	s := ""
+	for _, stmt := range stmts {
+		s += " " + fmt.Sprint(stmt)
+	}
+	return s
+}
+
+func test() {
+	u := Unary{}
+	u.operator = Token{l_type: MINUS, lexeme: "-", line: 1}
+	u.right = &Literal{value: 123}
+
+	// x := Unary{
+	// 	operator: Token{l_type: MINUS, lexeme: "-", line: 1},
+	// 	right:    Literal{value: 123},
+	// }
+	expression := Binary{
+		&Unary{
+			Token{l_type: MINUS, lexeme: "-", line: 1},
+			&Literal{value: 123},
+		},
+		Token{l_type: STAR, lexeme: "*", line: 1},
+		&Grouping{expression: &Literal{value: 45.67}},
+	}
+	fmt.Println(expression)
+}
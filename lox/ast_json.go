@@ -0,0 +1,653 @@
+package lox
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*
+Programs are exported as a JSON object holding the source and its statements.
+Each node is an object whose "type" is the name of its Go struct, with its
+fields under the same names as in expr.go and stmt.go:
+
+	{"type": "Print", "keyword": {"type": "PRINT", "lexeme": "print", ...},
+		"expression": {"type": "Literal", "token": {"type": "NUMBER", ...}, "value": 1}}
+
+Tokens keep their position, so errors in an imported program point at the
+same place in the source as they would have before it was exported.
+*/
+type astJSON struct {
+	Source     string            `json:"source,omitempty"`
+	Statements []json.RawMessage `json:"statements"`
+}
+
+// ExportJSON parses source and returns its syntax tree as JSON, without running it.
+func (l *Lox) ExportJSON(source string) ([]byte, error) {
+	diagnostics := NewDiagnostics(l.stderr)
+	statements := l.parse(source, diagnostics)
+	if diagnostics.HadError() {
+		return nil, diagnostics
+	}
+	return exportAST(source, statements)
+}
+
+/*
+RunJSON runs a program exported by ExportJSON. JSON that doesn't describe a
+syntax tree is reported like any other compile error and matches ErrCompile.
+*/
+func (l *Lox) RunJSON(data []byte) error {
+	statements, err := importAST(data)
+	if err != nil {
+		fmt.Fprintf(l.stderr, "Invalid syntax tree: %v\n", err)
+		return fmt.Errorf("%w: %v", ErrCompile, err)
+	}
+	return l.execute(statements, NewDiagnostics(l.stderr))
+}
+
+// exportAST serialises a parsed program and the source it came from
+func exportAST(source string, statements []Stmt) ([]byte, error) {
+	nodes := make([]any, len(statements))
+	for n, stmt := range statements {
+		nodes[n] = stmtJSON(stmt)
+	}
+	return json.MarshalIndent(map[string]any{"source": source, "statements": nodes}, "", "  ")
+}
+
+func stmtJSON(stmt Stmt) any {
+	switch s := stmt.(type) {
+	case *Block:
+		return node("Block", "statements", stmtsJSON(s.statements))
+	case *Break:
+		return node("Break", "keyword", tokenJSON(s.keyword))
+	case *Class:
+		var superclass any
+		if s.superclass != (Variable{}) {
+			superclass = exprJSON(&s.superclass)
+		}
+		methods := make([]any, len(s.methods))
+		for n := range s.methods {
+			methods[n] = stmtJSON(&s.methods[n])
+		}
+		return node("Class", "name", tokenJSON(s.name), "superclass", superclass, "methods", methods)
+	case *Continue:
+		return node("Continue", "keyword", tokenJSON(s.keyword))
+	case *Expression:
+		return node("Expression", "expression", exprJSON(s.expression))
+	case *Function:
+		return node("Function", "name", tokenJSON(s.name), "params", tokensJSON(s.params), "body", stmtsJSON(s.body))
+	case *If:
+		return node("If", "keyword", tokenJSON(s.keyword), "condition", exprJSON(s.condition), "thenBranch", stmtJSON(s.thenBranch), "elseBranch", stmtJSON(s.elseBranch))
+	case *Print:
+		return node("Print", "keyword", tokenJSON(s.keyword), "expression", exprJSON(s.expression))
+	case *Return:
+		return node("Return", "keyword", tokenJSON(s.keyword), "value", exprJSON(s.value))
+	case *Throw:
+		return node("Throw", "keyword", tokenJSON(s.keyword), "value", exprJSON(s.value))
+	case *Try:
+		var catchName any
+		if s.catchName.lexeme != "" {
+			catchName = tokenJSON(s.catchName)
+		}
+		return node("Try", "keyword", tokenJSON(s.keyword), "tryBranch", stmtsJSON(s.tryBranch), "catchName", catchName,
+			"catchBranch", stmtsJSON(s.catchBranch), "finallyBranch", stmtsJSON(s.finallyBranch))
+	case *Var:
+		return node("Var", "name", tokenJSON(s.name), "initializer", exprJSON(s.initializer))
+	case *While:
+		return node("While", "condition", exprJSON(s.condition), "body", stmtJSON(s.body), "increment", exprJSON(s.increment))
+	}
+	return nil
+}
+
+func exprJSON(expr Expr) any {
+	switch e := expr.(type) {
+	case *Assign:
+		return node("Assign", "name", tokenJSON(e.name), "value", exprJSON(e.value))
+	case *Binary:
+		return node("Binary", "left", exprJSON(e.left), "operator", tokenJSON(e.operator), "right", exprJSON(e.right))
+	case *Call:
+		return node("Call", "callee", exprJSON(e.callee), "paren", tokenJSON(e.paren), "arguments", exprsJSON(e.arguments))
+	case *Get:
+		return node("Get", "object", exprJSON(e.object), "name", tokenJSON(e.name))
+	case *Grouping:
+		return node("Grouping", "expression", exprJSON(e.expression))
+	case *Index:
+		return node("Index", "object", exprJSON(e.object), "bracket", tokenJSON(e.bracket), "index", exprJSON(e.index))
+	case *Lambda:
+		return node("Lambda", "keyword", tokenJSON(e.keyword), "params", tokensJSON(e.params), "body", stmtsJSON(e.body))
+	case *List:
+		return node("List", "bracket", tokenJSON(e.bracket), "elements", exprsJSON(e.elements))
+	case *Literal:
+		return node("Literal", "token", tokenJSON(e.token), "value", e.value)
+	case *Logical:
+		return node("Logical", "left", exprJSON(e.left), "operator", tokenJSON(e.operator), "right", exprJSON(e.right))
+	case *Map:
+		return node("Map", "brace", tokenJSON(e.brace), "keys", exprsJSON(e.keys), "values", exprsJSON(e.values))
+	case *Set:
+		return node("Set", "object", exprJSON(e.object), "name", tokenJSON(e.name), "value", exprJSON(e.value))
+	case *SetIndex:
+		return node("SetIndex", "object", exprJSON(e.object), "bracket", tokenJSON(e.bracket), "index", exprJSON(e.index), "value", exprJSON(e.value))
+	case *Super:
+		return node("Super", "keyword", tokenJSON(e.keyword), "method", tokenJSON(e.method))
+	case *This:
+		return node("This", "keyword", tokenJSON(e.keyword))
+	case *Unary:
+		return node("Unary", "operator", tokenJSON(e.operator), "right", exprJSON(e.right))
+	case *Variable:
+		return node("Variable", "name", tokenJSON(e.name))
+	}
+	return nil
+}
+
+// node builds a node's object from its type and pairs of field names and values
+func node(nodeType string, fields ...any) map[string]any {
+	m := map[string]any{"type": nodeType}
+	for n := 0; n < len(fields); n += 2 {
+		m[fields[n].(string)] = fields[n+1]
+	}
+	return m
+}
+
+func stmtsJSON(stmts []Stmt) []any {
+	nodes := make([]any, len(stmts))
+	for n, stmt := range stmts {
+		nodes[n] = stmtJSON(stmt)
+	}
+	return nodes
+}
+
+func exprsJSON(exprs []Expr) []any {
+	nodes := make([]any, len(exprs))
+	for n, expr := range exprs {
+		nodes[n] = exprJSON(expr)
+	}
+	return nodes
+}
+
+func tokenJSON(t Token) map[string]any {
+	m := map[string]any{
+		"type":   t.l_type.String(),
+		"lexeme": t.lexeme,
+		"line":   t.line,
+		"column": t.column,
+		"start":  t.start,
+		"length": t.length,
+	}
+	if t.literal != nil {
+		m["literal"] = t.literal
+	}
+	return m
+}
+
+func tokensJSON(tokens []Token) []any {
+	nodes := make([]any, len(tokens))
+	for n, token := range tokens {
+		nodes[n] = tokenJSON(token)
+	}
+	return nodes
+}
+
+// astImporter rebuilds an AST from JSON, giving every token the program's source
+type astImporter struct {
+	source     *string
+	tokenTypes map[string]TokenType
+}
+
+// importAST turns an exported program back into statements
+func importAST(data []byte) ([]Stmt, error) {
+	var program astJSON
+	if err := json.Unmarshal(data, &program); err != nil {
+		return nil, err
+	}
+	im := astImporter{source: &program.Source, tokenTypes: make(map[string]TokenType)}
+	for t := TokenType(0); t <= EOF; t++ {
+		im.tokenTypes[t.String()] = t
+	}
+
+	statements := make([]Stmt, len(program.Statements))
+	for n, raw := range program.Statements {
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		stmt, err := im.stmt(v)
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", n+1, err)
+		}
+		statements[n] = stmt
+	}
+	return statements, nil
+}
+
+// fields holds a node's JSON object
+type fields map[string]any
+
+func (im *astImporter) stmt(v any) (Stmt, error) {
+	f, nodeType, err := nodeFields(v)
+	if err != nil {
+		return nil, err
+	}
+	switch nodeType {
+	case "Block":
+		statements, err := im.stmts(f["statements"])
+		return &Block{statements}, err
+	case "Break":
+		keyword, err := im.token(f["keyword"])
+		return &Break{keyword}, err
+	case "Class":
+		name, err := im.token(f["name"])
+		if err != nil {
+			return nil, err
+		}
+		var superclass Variable
+		if f["superclass"] != nil {
+			expr, err := im.expr(f["superclass"])
+			if err != nil {
+				return nil, err
+			}
+			v, ok := expr.(*Variable)
+			if !ok {
+				return nil, fmt.Errorf("superclass must be a Variable")
+			}
+			superclass = *v
+		}
+		list, err := array(f["methods"])
+		if err != nil {
+			return nil, err
+		}
+		methods := make([]Function, len(list))
+		for n, m := range list {
+			stmt, err := im.stmt(m)
+			if err != nil {
+				return nil, err
+			}
+			method, ok := stmt.(*Function)
+			if !ok {
+				return nil, fmt.Errorf("class methods must be Functions")
+			}
+			methods[n] = *method
+		}
+		return &Class{name, superclass, methods}, nil
+	case "Continue":
+		keyword, err := im.token(f["keyword"])
+		return &Continue{keyword}, err
+	case "Expression":
+		expression, err := im.expr(f["expression"])
+		return &Expression{expression}, err
+	case "Function":
+		name, err := im.token(f["name"])
+		if err != nil {
+			return nil, err
+		}
+		params, err := im.tokens(f["params"])
+		if err != nil {
+			return nil, err
+		}
+		body, err := im.stmts(f["body"])
+		return &Function{name, params, body}, err
+	case "If":
+		keyword, err := im.token(f["keyword"])
+		if err != nil {
+			return nil, err
+		}
+		condition, err := im.expr(f["condition"])
+		if err != nil {
+			return nil, err
+		}
+		thenBranch, err := im.stmt(f["thenBranch"])
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := im.optionalStmt(f["elseBranch"])
+		return &If{keyword, condition, thenBranch, elseBranch}, err
+	case "Print":
+		keyword, err := im.token(f["keyword"])
+		if err != nil {
+			return nil, err
+		}
+		expression, err := im.expr(f["expression"])
+		return &Print{keyword, expression}, err
+	case "Return":
+		keyword, err := im.token(f["keyword"])
+		if err != nil {
+			return nil, err
+		}
+		value, err := im.optionalExpr(f["value"])
+		return &Return{keyword, value}, err
+	case "Throw":
+		keyword, err := im.token(f["keyword"])
+		if err != nil {
+			return nil, err
+		}
+		value, err := im.expr(f["value"])
+		return &Throw{keyword, value}, err
+	case "Try":
+		keyword, err := im.token(f["keyword"])
+		if err != nil {
+			return nil, err
+		}
+		tryBranch, err := im.stmts(f["tryBranch"])
+		if err != nil {
+			return nil, err
+		}
+		var catchName Token
+		if f["catchName"] != nil {
+			catchName, err = im.token(f["catchName"])
+			if err != nil {
+				return nil, err
+			}
+		}
+		catchBranch, err := im.stmts(f["catchBranch"])
+		if err != nil {
+			return nil, err
+		}
+		finallyBranch, err := im.stmts(f["finallyBranch"])
+		return &Try{keyword, tryBranch, catchName, catchBranch, finallyBranch}, err
+	case "Var":
+		name, err := im.token(f["name"])
+		if err != nil {
+			return nil, err
+		}
+		initializer, err := im.optionalExpr(f["initializer"])
+		return &Var{name, initializer}, err
+	case "While":
+		condition, err := im.expr(f["condition"])
+		if err != nil {
+			return nil, err
+		}
+		body, err := im.stmt(f["body"])
+		if err != nil {
+			return nil, err
+		}
+		increment, err := im.optionalExpr(f["increment"])
+		return &While{condition, body, increment}, err
+	}
+	return nil, fmt.Errorf("unknown statement type %q", nodeType)
+}
+
+func (im *astImporter) expr(v any) (Expr, error) {
+	f, nodeType, err := nodeFields(v)
+	if err != nil {
+		return nil, err
+	}
+	switch nodeType {
+	case "Assign":
+		name, err := im.token(f["name"])
+		if err != nil {
+			return nil, err
+		}
+		value, err := im.expr(f["value"])
+		return &Assign{name, value}, err
+	case "Binary", "Logical":
+		left, err := im.expr(f["left"])
+		if err != nil {
+			return nil, err
+		}
+		operators := []TokenType{BANG_EQUAL, EQUAL_EQUAL, GREATER, GREATER_EQUAL, LESS, LESS_EQUAL, MINUS, PLUS, SLASH, STAR}
+		if nodeType == "Logical" {
+			operators = []TokenType{AND, OR}
+		}
+		operator, err := im.operator(f["operator"], nodeType, operators...)
+		if err != nil {
+			return nil, err
+		}
+		right, err := im.expr(f["right"])
+		if nodeType == "Logical" {
+			return &Logical{left, operator, right}, err
+		}
+		return &Binary{left, operator, right}, err
+	case "Call":
+		callee, err := im.expr(f["callee"])
+		if err != nil {
+			return nil, err
+		}
+		paren, err := im.token(f["paren"])
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := im.exprs(f["arguments"])
+		return &Call{callee, paren, arguments}, err
+	case "Get":
+		object, err := im.expr(f["object"])
+		if err != nil {
+			return nil, err
+		}
+		name, err := im.token(f["name"])
+		return &Get{object, name}, err
+	case "Grouping":
+		expression, err := im.expr(f["expression"])
+		return &Grouping{expression}, err
+	case "Index":
+		object, err := im.expr(f["object"])
+		if err != nil {
+			return nil, err
+		}
+		bracket, err := im.token(f["bracket"])
+		if err != nil {
+			return nil, err
+		}
+		index, err := im.expr(f["index"])
+		return &Index{object, bracket, index}, err
+	case "Lambda":
+		keyword, err := im.token(f["keyword"])
+		if err != nil {
+			return nil, err
+		}
+		params, err := im.tokens(f["params"])
+		if err != nil {
+			return nil, err
+		}
+		body, err := im.stmts(f["body"])
+		return &Lambda{keyword, params, body}, err
+	case "List":
+		bracket, err := im.token(f["bracket"])
+		if err != nil {
+			return nil, err
+		}
+		elements, err := im.exprs(f["elements"])
+		return &List{bracket, elements}, err
+	case "Literal":
+		token, err := im.token(f["token"])
+		if err != nil {
+			return nil, err
+		}
+		switch value := f["value"].(type) {
+		case nil, bool, float64, string:
+			return &Literal{token, value}, nil
+		}
+		return nil, fmt.Errorf("literal values must be numbers, strings, booleans or null")
+	case "Map":
+		brace, err := im.token(f["brace"])
+		if err != nil {
+			return nil, err
+		}
+		keys, err := im.exprs(f["keys"])
+		if err != nil {
+			return nil, err
+		}
+		values, err := im.exprs(f["values"])
+		if err == nil && len(keys) != len(values) {
+			err = fmt.Errorf("map has %d keys but %d values", len(keys), len(values))
+		}
+		return &Map{brace, keys, values}, err
+	case "Set":
+		object, err := im.expr(f["object"])
+		if err != nil {
+			return nil, err
+		}
+		name, err := im.token(f["name"])
+		if err != nil {
+			return nil, err
+		}
+		value, err := im.expr(f["value"])
+		return &Set{object, name, value}, err
+	case "SetIndex":
+		object, err := im.expr(f["object"])
+		if err != nil {
+			return nil, err
+		}
+		bracket, err := im.token(f["bracket"])
+		if err != nil {
+			return nil, err
+		}
+		index, err := im.expr(f["index"])
+		if err != nil {
+			return nil, err
+		}
+		value, err := im.expr(f["value"])
+		return &SetIndex{object, bracket, index, value}, err
+	case "Super":
+		keyword, err := im.keyword(f["keyword"], nodeType, SUPER, "super")
+		if err != nil {
+			return nil, err
+		}
+		method, err := im.token(f["method"])
+		return &Super{keyword, method}, err
+	case "This":
+		keyword, err := im.keyword(f["keyword"], nodeType, THIS, "this")
+		return &This{keyword}, err
+	case "Unary":
+		operator, err := im.operator(f["operator"], nodeType, BANG, MINUS)
+		if err != nil {
+			return nil, err
+		}
+		right, err := im.expr(f["right"])
+		return &Unary{operator, right}, err
+	case "Variable":
+		name, err := im.token(f["name"])
+		return &Variable{name}, err
+	}
+	return nil, fmt.Errorf("unknown expression type %q", nodeType)
+}
+
+func (im *astImporter) optionalStmt(v any) (Stmt, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return im.stmt(v)
+}
+
+func (im *astImporter) optionalExpr(v any) (Expr, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return im.expr(v)
+}
+
+func (im *astImporter) stmts(v any) ([]Stmt, error) {
+	list, err := array(v)
+	if err != nil {
+		return nil, err
+	}
+	stmts := make([]Stmt, len(list))
+	for n, item := range list {
+		stmts[n], err = im.stmt(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stmts, nil
+}
+
+func (im *astImporter) exprs(v any) ([]Expr, error) {
+	list, err := array(v)
+	if err != nil {
+		return nil, err
+	}
+	exprs := make([]Expr, len(list))
+	for n, item := range list {
+		exprs[n], err = im.expr(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return exprs, nil
+}
+
+func (im *astImporter) tokens(v any) ([]Token, error) {
+	list, err := array(v)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]Token, len(list))
+	for n, item := range list {
+		tokens[n], err = im.token(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+func (im *astImporter) token(v any) (Token, error) {
+	f, ok := v.(map[string]any)
+	if !ok {
+		return Token{}, fmt.Errorf("expected a token, got %v", v)
+	}
+	typeName, _ := f["type"].(string)
+	l_type, ok := im.tokenTypes[typeName]
+	if !ok {
+		return Token{}, fmt.Errorf("unknown token type %q", typeName)
+	}
+	lexeme, ok := f["lexeme"].(string)
+	if !ok {
+		return Token{}, fmt.Errorf("token has no lexeme")
+	}
+	var position [4]int
+	for n, name := range []string{"line", "column", "start", "length"} {
+		number, ok := f[name].(float64)
+		if !ok || number < 0 || number != float64(int(number)) {
+			return Token{}, fmt.Errorf("token %q has no valid %s", lexeme, name)
+		}
+		position[n] = int(number)
+	}
+	return Token{l_type, lexeme, f["literal"], position[0], position[1], position[2], position[3], im.source}, nil
+}
+
+// operator reads an operator token, checking it's one the parser would have put in a nodeType node
+func (im *astImporter) operator(v any, nodeType string, allowed ...TokenType) (Token, error) {
+	operator, err := im.token(v)
+	if err != nil {
+		return Token{}, err
+	}
+	for _, l_type := range allowed {
+		if operator.l_type == l_type {
+			return operator, nil
+		}
+	}
+	return Token{}, fmt.Errorf("%s can't be the operator of a %s", operator.l_type, nodeType)
+}
+
+// keyword reads the keyword of a node that's looked up by its name, like 'this', which must be exactly that keyword
+func (im *astImporter) keyword(v any, nodeType string, l_type TokenType, lexeme string) (Token, error) {
+	keyword, err := im.token(v)
+	if err != nil {
+		return Token{}, err
+	}
+	if keyword.l_type != l_type || keyword.lexeme != lexeme {
+		return Token{}, fmt.Errorf("%s %q can't be the keyword of a %s", keyword.l_type, keyword.lexeme, nodeType)
+	}
+	return keyword, nil
+}
+
+func nodeFields(v any) (fields, string, error) {
+	f, ok := v.(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("expected a node, got %v", v)
+	}
+	nodeType, ok := f["type"].(string)
+	if !ok {
+		return nil, "", fmt.Errorf("node has no type")
+	}
+	return f, nodeType, nil
+}
+
+func array(v any) ([]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %v", v)
+	}
+	return list, nil
+}
@@ -0,0 +1,133 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Running a script's exported syntax tree should look exactly like running the script
+func TestJSONRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../lox_programs/*.lox")
+	if err != nil {
+		panic(err)
+	}
+	for _, backend := range backends {
+		for _, file := range files {
+			backend, file := backend, file
+			t.Run(backend.name+"/"+filepath.Base(file), func(t *testing.T) {
+				source, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var stderr bytes.Buffer
+				data, err := New(WithStderr(&stderr)).ExportJSON(string(source))
+				if err != nil {
+					// Scripts that don't parse have nothing to export
+					if !errors.Is(err, ErrCompile) || stderr.Len() == 0 {
+						t.Fatalf("export failed: %v", err)
+					}
+					return
+				}
+
+				var directOut, directErr, importedOut, importedErr bytes.Buffer
+				directCode := exitCode(New(append(backend.options, WithStdout(&directOut), WithStderr(&directErr))...).Run(string(source)))
+				importedCode := exitCode(New(append(backend.options, WithStdout(&importedOut), WithStderr(&importedErr))...).RunJSON(data))
+
+				if importedOut.String() != directOut.String() || importedErr.String() != directErr.String() {
+					t.Errorf("imported program printed\n%s%s\nexpected\n%s%s", importedOut.String(), importedErr.String(), directOut.String(), directErr.String())
+				}
+				if importedCode != directCode {
+					t.Errorf("exit code was %d, expected %d", importedCode, directCode)
+				}
+			})
+		}
+	}
+}
+
+func TestJSONExportIsStable(t *testing.T) {
+	source := "class A < B { init(x) { this.x = [x, {\"k\": -1}][0]; } }\nvar f = fun (a) { return super.m; };\n"
+	first, err := New().ExportJSON(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := importAST(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := exportAST(source, statements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("re-exported JSON differs:\n%s\nexpected\n%s", second, first)
+	}
+}
+
+// literalJSON is a number literal node at the start of the source
+func literalJSON(value int) string {
+	return fmt.Sprintf(`{"type": "Literal", "token": {"type": "NUMBER", "lexeme": "%d", "line": 1, "column": 1, "start": 0, "length": 1}, "value": %d}`, value, value)
+}
+
+func TestRunJSONRejectsBadTrees(t *testing.T) {
+	tests := []struct {
+		json    string
+		message string
+	}{
+		{`{`, "unexpected end of JSON input"},
+		{`{"statements": [{"type": "Loop"}]}`, `statement 1: unknown statement type "Loop"`},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "Variable", "name": {"type": "WORD", "lexeme": "x", "line": 1, "column": 0, "start": 0, "length": 1}}}]}`, `unknown token type "WORD"`},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "Literal", "value": 1}}]}`, "expected a token"},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "Literal",
+			"token": {"type": "NUMBER", "lexeme": "1", "line": 1, "column": 1, "start": 0, "length": 1}, "value": [1]}}]}`, "literal values must be"},
+		{`{"statements": [{"type": "Expression"}]}`, "expected a node"},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "Binary", "left": ` + literalJSON(1) + `, "right": ` + literalJSON(2) + `,
+			"operator": {"type": "AND", "lexeme": "and", "line": 1, "column": 9, "start": 8, "length": 3}}}]}`, "AND can't be the operator of a Binary"},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "Logical", "left": ` + literalJSON(1) + `, "right": ` + literalJSON(2) + `,
+			"operator": {"type": "PLUS", "lexeme": "+", "line": 1, "column": 9, "start": 8, "length": 1}}}]}`, "PLUS can't be the operator of a Logical"},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "Unary", "right": ` + literalJSON(2) + `,
+			"operator": {"type": "STAR", "lexeme": "*", "line": 1, "column": 7, "start": 6, "length": 1}}}]}`, "STAR can't be the operator of a Unary"},
+		{`{"statements": [{"type": "Expression", "expression": {"type": "This",
+			"keyword": {"type": "IDENTIFIER", "lexeme": "this", "line": 1, "column": 7, "start": 6, "length": 4}}}]}`, `IDENTIFIER "this" can't be the keyword of a This`},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		err := New(WithStdout(&stdout), WithStderr(&stderr)).RunJSON([]byte(test.json))
+		if !errors.Is(err, ErrCompile) {
+			t.Errorf("%s: got %v, expected a compile error", test.json, err)
+		}
+		if !strings.Contains(stderr.String(), test.message) {
+			t.Errorf("%s: reported %q, expected %q", test.json, stderr.String(), test.message)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("%s: printed %q", test.json, stdout.String())
+		}
+	}
+}
+
+// The resolver looks 'super' up by name, so renaming it would reach a variable that isn't there
+func TestRunJSONRejectsRenamedSuper(t *testing.T) {
+	exported, err := New().ExportJSON("class A { m() {} }\nclass B < A { m() { super.m(); } }\nB().m();\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed := bytes.Replace(exported, []byte(`"lexeme": "super"`), []byte(`"lexeme": "zzz"`), 1)
+	if bytes.Equal(renamed, exported) {
+		t.Fatalf("exported JSON has no 'super' token:\n%s", exported)
+	}
+
+	for _, backend := range backends {
+		var stderr bytes.Buffer
+		err := New(append(backend.options, WithStderr(&stderr))...).RunJSON(renamed)
+		if !errors.Is(err, ErrCompile) {
+			t.Errorf("%s: got %v, expected a compile error", backend.name, err)
+		}
+		if expected := `SUPER "zzz" can't be the keyword of a Super`; !strings.Contains(stderr.String(), expected) {
+			t.Errorf("%s: reported %q, expected %q", backend.name, stderr.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package lox
+
+import "fmt"
+
+// Deep enough for real programs, shallow enough that runaway recursion doesn't exhaust the Go stack
+const maxFrames = 10000
+
+// Identical frames shown in a row before the rest are summarised
+const maxRepeatedFrames = 3
+
+// callFrame is a Lox function or class call that's currently running
+type callFrame struct {
+	function string
+	// Where the call was made, in the caller
+	callSite Token
+}
+
+// StackFrame is one line of a runtime error's traceback
+type StackFrame struct {
+	Function string
+	Line     int
+}
+
+func (f StackFrame) String() string {
+	if f.Function == "" {
+		return fmt.Sprintf("[line %d] in script", f.Line)
+	}
+	return fmt.Sprintf("[line %d] in %s()", f.Line, f.Function)
+}
+
+func (i *interpreter) pushFrame(function string) error {
+	if len(i.frames) >= maxFrames {
+		return NewRuntimeError(i.callSite, "Stack overflow.")
+	}
+	i.frames = append(i.frames, callFrame{function, i.callSite})
+	return nil
+}
+
+func (i *interpreter) popFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+/*
+traced records the current call stack on a runtime error the first time it
+unwinds through a frame, or reaches the top level, so the traceback shows
+where it was raised rather than where it was reported.
+*/
+func (i *interpreter) traced(err error) error {
+	re, ok := err.(*RuntimeError)
+	if !ok || re.trace != nil {
+		return err
+	}
+
+	// Innermost first: each frame's line is where the next frame in was called from
+	line := re.token.line
+	for n := len(i.frames) - 1; n >= 0; n-- {
+		re.trace = append(re.trace, StackFrame{i.frames[n].function, line})
+		line = i.frames[n].callSite.line
+	}
+	re.trace = append(re.trace, StackFrame{"", line})
+	return re
+}
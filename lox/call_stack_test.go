@@ -0,0 +1,67 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRuntimeErrorTraceback(t *testing.T) {
+	var stderr bytes.Buffer
+	l := New(WithStderr(&stderr))
+	err := l.Run(`class Shape {
+  init(sides) {
+    this.sides = sides;
+    this.check();
+  }
+  check() {
+    var fail = fun () { return -"sides"; };
+    fail();
+  }
+}
+fun build() {
+  return Shape(3);
+}
+build();`)
+
+	var re *RuntimeError
+	if !errors.As(err, &re) {
+		t.Fatalf("Run() returned %v, expected a *RuntimeError", err)
+	}
+	expected := []StackFrame{
+		{"anonymous", 7},
+		{"Shape.check", 8},
+		{"Shape", 4},
+		{"build", 12},
+		{"", 14},
+	}
+	if !reflect.DeepEqual(re.trace, expected) {
+		t.Errorf("got trace %v, expected %v", re.trace, expected)
+	}
+
+	expectedStderr := `operand must be a number.
+[line 7] in anonymous()
+   7 |     var fail = fun () { return -"sides"; };
+     |                                ^
+[line 8] in Shape.check()
+[line 4] in Shape()
+[line 12] in build()
+[line 14] in script
+`
+	if got := stderr.String(); got != expectedStderr {
+		t.Errorf("stderr was\n%s\nexpected\n%s", got, expectedStderr)
+	}
+}
+
+func TestCaughtErrorsLeaveStackBalanced(t *testing.T) {
+	l := New(WithStderr(new(bytes.Buffer)))
+	err := l.Run(`fun boom() { throw "boom"; }
+try { boom(); } catch (e) {}`)
+	if err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if len(l.interpreter.frames) != 0 {
+		t.Errorf("%d frames left on the stack after the error was caught", len(l.interpreter.frames))
+	}
+}
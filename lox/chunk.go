@@ -0,0 +1,99 @@
+package lox
+
+import "sort"
+
+//go:generate stringer -type=OpCode
+type OpCode byte
+
+// Operands are two bytes, big-endian, unless noted
+const (
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	OP_SET_GLOBAL
+	OP_GET_UPVALUE
+	OP_SET_UPVALUE
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+	OP_GET_SUPER
+	OP_GET_INDEX
+	OP_SET_INDEX
+	OP_EQUAL
+	OP_GREATER
+	OP_GREATER_EQUAL
+	OP_LESS
+	OP_LESS_EQUAL
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_NOT
+	OP_NEGATE
+	OP_PRINT
+	OP_JUMP
+	OP_JUMP_IF_FALSE
+	OP_LOOP
+	OP_CALL
+	// Function constant, then a one byte is-local flag and an index for each upvalue
+	OP_CLOSURE
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+	OP_CLASS
+	OP_INHERIT
+	OP_METHOD
+	OP_LIST
+	OP_MAP
+	OP_THROW
+	// Jump offset to the handler that runs if the protected code raises an error
+	OP_PUSH_HANDLER
+	OP_POP_HANDLER
+	OP_RETHROW
+	// Replaces the error a handler was given with the value a catch block sees
+	OP_ERROR_VALUE
+)
+
+/*
+chunk is a compiled function body. Rather than a bare line per byte, the
+line table records the token each run of instructions came from, so runtime
+errors can point at the same place in the source as the tree-walker's.
+*/
+type chunk struct {
+	code      []byte
+	constants []any
+	tokens    []tokenRun
+}
+
+// tokenRun says the code from start up to the next run was compiled from token
+type tokenRun struct {
+	start int
+	token Token
+}
+
+func (c *chunk) write(b byte, token Token) {
+	if n := len(c.tokens); n == 0 || c.tokens[n-1].token.start != token.start || c.tokens[n-1].token.line != token.line {
+		c.tokens = append(c.tokens, tokenRun{len(c.code), token})
+	}
+	c.code = append(c.code, b)
+}
+
+func (c *chunk) addConstant(value any) int {
+	c.constants = append(c.constants, value)
+	return len(c.constants) - 1
+}
+
+// tokenAt finds the token the instruction containing offset was compiled from
+func (c *chunk) tokenAt(offset int) Token {
+	n := sort.Search(len(c.tokens), func(i int) bool {
+		return c.tokens[i].start > offset
+	})
+	if n == 0 {
+		return Token{}
+	}
+	return c.tokens[n-1].token
+}
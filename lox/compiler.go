@@ -0,0 +1,678 @@
+package lox
+
+import (
+	"math"
+
+	"github.com/charlesdunbar/lox-go/functiontype"
+)
+
+// Operands are two bytes, which limits how many locals, constants and upvalues a function has and how far it can jump
+const maxOperand = math.MaxUint16
+
+type local struct {
+	name     string
+	depth    int
+	captured bool
+}
+
+type upvalueRef struct {
+	index   int
+	isLocal bool
+}
+
+// loopContext collects the jumps out of a loop body until their targets are known
+type loopContext struct {
+	scopeDepth int
+	// How many try statements were open when the loop started
+	tries     int
+	breaks    []int
+	continues []int
+}
+
+// tryContext is an open try statement, which returns, breaks and continues have to leave properly
+type tryContext struct {
+	finally []Stmt
+	// Whether one of the statement's handlers is pushed at this point in the code
+	handler     bool
+	localsStart int
+}
+
+// localRange is locals that a finally block copied into them can't see
+type localRange struct {
+	start, end int
+}
+
+/*
+compiler turns a resolved AST into bytecode, one compiler per function.
+The resolver has already reported every static error, so the compiler only
+has to worry about the limits of the bytecode format.
+*/
+type compiler struct {
+	enclosing  *compiler
+	function   *vmFunction
+	kind       functiontype.FunctionType
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+	loops      []*loopContext
+	tries      []*tryContext
+	hidden     []localRange
+	// Constant pool indexes of identifiers, so each name is only stored once
+	names map[string]int
+	// The token the code being emitted came from, for the line table
+	token       Token
+	diagnostics *Diagnostics
+}
+
+func newCompiler(enclosing *compiler, function *vmFunction, kind functiontype.FunctionType, d *Diagnostics) *compiler {
+	c := &compiler{
+		enclosing:   enclosing,
+		function:    function,
+		kind:        kind,
+		names:       make(map[string]int),
+		diagnostics: d,
+	}
+	if enclosing != nil {
+		c.token = enclosing.token
+	}
+	// Slot zero holds the function being called, or the receiver for methods
+	slot := ""
+	if kind == functiontype.METHOD || kind == functiontype.INITIALIZER {
+		slot = "this"
+	}
+	c.locals = append(c.locals, local{name: slot})
+	return c
+}
+
+// compile turns a program into the function the VM runs for its top level
+func compile(statements []Stmt, d *Diagnostics) *vmFunction {
+	c := newCompiler(nil, &vmFunction{isScript: true}, functiontype.NONE, d)
+	c.statements(statements)
+	c.emitReturn()
+	return c.function
+}
+
+// compileExpression turns a single expression into a script that returns its value
+func compileExpression(expr Expr, d *Diagnostics) *vmFunction {
+	c := newCompiler(nil, &vmFunction{isScript: true}, functiontype.NONE, d)
+	c.expression(expr)
+	c.emit(OP_RETURN)
+	return c.function
+}
+
+func (c *compiler) statements(statements []Stmt) {
+	for _, stmt := range statements {
+		c.statement(stmt)
+	}
+}
+
+func (c *compiler) statement(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *Block:
+		c.block(s.statements)
+	case *Break:
+		c.token = s.keyword
+		loop := c.loops[len(c.loops)-1]
+		c.unwind(loop.tries)
+		c.popLocals(loop.scopeDepth)
+		loop.breaks = append(loop.breaks, c.emitJump(OP_JUMP))
+	case *Class:
+		c.class(s)
+	case *Continue:
+		c.token = s.keyword
+		loop := c.loops[len(c.loops)-1]
+		c.unwind(loop.tries)
+		c.popLocals(loop.scopeDepth)
+		loop.continues = append(loop.continues, c.emitJump(OP_JUMP))
+	case *Expression:
+		c.expression(s.expression)
+		c.emit(OP_POP)
+	case *Function:
+		c.token = s.name
+		// Defined before the body is compiled so the function can call itself
+		if c.scopeDepth > 0 {
+			c.addLocal(s.name.lexeme)
+		}
+		c.closure(s.name.lexeme, "", s.params, s.body, functiontype.FUNCTION)
+		c.token = s.name
+		c.defineGlobal(s.name)
+	case *If:
+		c.expression(s.condition)
+		thenJump := c.emitJump(OP_JUMP_IF_FALSE)
+		c.emit(OP_POP)
+		c.statement(s.thenBranch)
+		elseJump := c.emitJump(OP_JUMP)
+		c.patchJump(thenJump)
+		c.emit(OP_POP)
+		if s.elseBranch != nil {
+			c.statement(s.elseBranch)
+		}
+		c.patchJump(elseJump)
+	case *Print:
+		c.token = s.keyword
+		c.expression(s.expression)
+		c.token = s.keyword
+		c.emit(OP_PRINT)
+	case *Return:
+		c.token = s.keyword
+		if c.kind == functiontype.INITIALIZER {
+			c.emitShort(OP_GET_LOCAL, 0)
+		} else if s.value != nil {
+			c.expression(s.value)
+		} else {
+			c.emit(OP_NIL)
+		}
+		if len(c.tries) > 0 {
+			// Keep the value in a slot of its own while the finally blocks run
+			c.addLocal("")
+			c.unwind(0)
+			c.locals = c.locals[:len(c.locals)-1]
+		}
+		c.token = s.keyword
+		c.emit(OP_RETURN)
+	case *Throw:
+		c.expression(s.value)
+		c.token = s.keyword
+		c.emit(OP_THROW)
+	case *Try:
+		c.try(s)
+	case *Var:
+		c.token = s.name
+		if s.initializer != nil {
+			c.expression(s.initializer)
+		} else {
+			c.emit(OP_NIL)
+		}
+		c.token = s.name
+		if c.scopeDepth > 0 {
+			c.addLocal(s.name.lexeme)
+		}
+		c.defineGlobal(s.name)
+	case *While:
+		c.while(s)
+	}
+}
+
+func (c *compiler) block(statements []Stmt) {
+	c.beginScope()
+	c.statements(statements)
+	c.endScope()
+}
+
+func (c *compiler) while(s *While) {
+	loop := &loopContext{scopeDepth: c.scopeDepth, tries: len(c.tries)}
+	start := len(c.function.chunk.code)
+	c.expression(s.condition)
+	exit := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emit(OP_POP)
+
+	c.loops = append(c.loops, loop)
+	c.statement(s.body)
+	c.loops = c.loops[:len(c.loops)-1]
+
+	// A continue still runs the increment of a desugared for loop
+	for _, jump := range loop.continues {
+		c.patchJump(jump)
+	}
+	if s.increment != nil {
+		c.expression(s.increment)
+		c.emit(OP_POP)
+	}
+	c.emitLoop(start)
+
+	c.patchJump(exit)
+	c.emit(OP_POP)
+	for _, jump := range loop.breaks {
+		c.patchJump(jump)
+	}
+}
+
+/*
+try compiles a try statement. Errors raised in the try block jump to a
+handler, which the VM gives the error on top of the stack:
+
+	PUSH_HANDLER catch; <try>; POP_HANDLER; JUMP done
+	catch:   PUSH_HANDLER rethrow; <catch>; POP_HANDLER; JUMP done
+	rethrow: <finally>; RETHROW
+	done:    <finally>
+
+Returns, breaks and continues leave through copies of the finally block, see unwind.
+*/
+func (c *compiler) try(s *Try) {
+	hasCatch := s.catchName.lexeme != ""
+	hasFinally := len(s.finallyBranch) > 0
+	try := &tryContext{finally: s.finallyBranch, handler: true, localsStart: len(c.locals)}
+
+	c.token = s.keyword
+	toHandler := c.emitHandler(try.localsStart)
+	c.tries = append(c.tries, try)
+	c.block(s.tryBranch)
+	c.token = s.keyword
+	c.emit(OP_POP_HANDLER)
+	try.handler = false
+	var done []int
+	done = append(done, c.emitJump(OP_JUMP))
+
+	c.patchJump(toHandler)
+	if hasCatch {
+		c.beginScope()
+		c.token = s.catchName
+		c.emit(OP_ERROR_VALUE)
+		c.addLocal(s.catchName.lexeme)
+		var toRethrow int
+		if hasFinally {
+			toRethrow = c.emitHandler(try.localsStart)
+			try.handler = true
+		}
+		c.statements(s.catchBranch)
+		c.token = s.keyword
+		if hasFinally {
+			c.emit(OP_POP_HANDLER)
+			try.handler = false
+		}
+		c.endScope()
+		if hasFinally {
+			done = append(done, c.emitJump(OP_JUMP))
+			c.patchJump(toRethrow)
+		}
+	}
+	c.tries = c.tries[:len(c.tries)-1]
+
+	if hasFinally {
+		// The error being rethrown sits in a slot of its own under the finally block's locals
+		c.beginScope()
+		c.addLocal("")
+		c.block(s.finallyBranch)
+		c.token = s.keyword
+		c.emit(OP_RETHROW)
+		c.locals = c.locals[:len(c.locals)-1]
+		c.scopeDepth--
+	}
+
+	for _, jump := range done {
+		c.patchJump(jump)
+	}
+	if hasFinally {
+		c.block(s.finallyBranch)
+	}
+}
+
+/*
+unwind leaves the try statements from the innermost down to tries[downTo],
+popping their handlers and running their finally blocks, before a return,
+break or continue jumps out of them.
+*/
+func (c *compiler) unwind(downTo int) {
+	for n := len(c.tries) - 1; n >= downTo; n-- {
+		try := c.tries[n]
+		if try.handler {
+			c.emit(OP_POP_HANDLER)
+		}
+		if len(try.finally) > 0 {
+			// The finally block is compiled where it would run, so it mustn't see the try block's variables
+			tries := c.tries
+			c.tries = c.tries[:n]
+			c.hidden = append(c.hidden, localRange{try.localsStart, len(c.locals)})
+			c.block(try.finally)
+			c.hidden = c.hidden[:len(c.hidden)-1]
+			c.tries = tries
+		}
+	}
+}
+
+func (c *compiler) class(s *Class) {
+	c.token = s.name
+	c.emitShort(OP_CLASS, c.identifier(s.name.lexeme))
+	if c.scopeDepth > 0 {
+		c.addLocal(s.name.lexeme)
+	}
+	c.defineGlobal(s.name)
+
+	hasSuperclass := s.superclass != (Variable{})
+	if hasSuperclass {
+		c.expression(&s.superclass)
+		// Methods find their superclass through this, like the interpreter's environment
+		c.beginScope()
+		c.addLocal("super")
+		c.variable(s.name, false)
+		c.token = s.superclass.name
+		c.emit(OP_INHERIT)
+	}
+
+	c.variable(s.name, false)
+	for _, method := range s.methods {
+		kind := functiontype.METHOD
+		if method.name.lexeme == "init" {
+			kind = functiontype.INITIALIZER
+		}
+		c.token = method.name
+		c.closure(method.name.lexeme, s.name.lexeme, method.params, method.body, kind)
+		c.token = method.name
+		c.emitShort(OP_METHOD, c.identifier(method.name.lexeme))
+	}
+	c.emit(OP_POP)
+
+	if hasSuperclass {
+		c.endScope()
+	}
+}
+
+// closure compiles a function body with its own compiler and emits the closure that creates it
+func (c *compiler) closure(name, className string, params []Token, body []Stmt, kind functiontype.FunctionType) {
+	f := newCompiler(c, &vmFunction{name: name, className: className, arity: len(params)}, kind, c.diagnostics)
+	f.beginScope()
+	for _, param := range params {
+		f.token = param
+		f.addLocal(param.lexeme)
+	}
+	f.statements(body)
+	f.emitReturn()
+	f.function.upvalueCount = len(f.upvalues)
+
+	c.emitShort(OP_CLOSURE, c.makeConstant(f.function))
+	for _, upvalue := range f.upvalues {
+		isLocal := byte(0)
+		if upvalue.isLocal {
+			isLocal = 1
+		}
+		c.function.chunk.write(isLocal, c.token)
+		c.writeShort(upvalue.index)
+	}
+}
+
+func (c *compiler) expression(expr Expr) {
+	switch e := expr.(type) {
+	case *Assign:
+		c.expression(e.value)
+		c.variable(e.name, true)
+	case *Binary:
+		c.expression(e.left)
+		c.expression(e.right)
+		c.token = e.operator
+		switch e.operator.l_type {
+		case BANG_EQUAL:
+			c.emit(OP_EQUAL)
+			c.emit(OP_NOT)
+		case EQUAL_EQUAL:
+			c.emit(OP_EQUAL)
+		case GREATER:
+			c.emit(OP_GREATER)
+		case GREATER_EQUAL:
+			c.emit(OP_GREATER_EQUAL)
+		case LESS:
+			c.emit(OP_LESS)
+		case LESS_EQUAL:
+			c.emit(OP_LESS_EQUAL)
+		case MINUS:
+			c.emit(OP_SUBTRACT)
+		case PLUS:
+			c.emit(OP_ADD)
+		case SLASH:
+			c.emit(OP_DIVIDE)
+		case STAR:
+			c.emit(OP_MULTIPLY)
+		}
+	case *Call:
+		c.expression(e.callee)
+		for _, argument := range e.arguments {
+			c.expression(argument)
+		}
+		c.token = e.paren
+		c.emitShort(OP_CALL, len(e.arguments))
+	case *Get:
+		c.expression(e.object)
+		c.token = e.name
+		c.emitShort(OP_GET_PROPERTY, c.identifier(e.name.lexeme))
+	case *Grouping:
+		c.expression(e.expression)
+	case *Index:
+		c.expression(e.object)
+		c.expression(e.index)
+		c.token = e.bracket
+		c.emit(OP_GET_INDEX)
+	case *Lambda:
+		c.token = e.keyword
+		c.closure("", "", e.params, e.body, functiontype.FUNCTION)
+	case *List:
+		for _, element := range e.elements {
+			c.expression(element)
+		}
+		c.token = e.bracket
+		c.emitShort(OP_LIST, c.count(len(e.elements), "Too many elements in a list literal."))
+	case *Literal:
+		c.token = e.token
+		switch e.value {
+		case nil:
+			c.emit(OP_NIL)
+		case true:
+			c.emit(OP_TRUE)
+		case false:
+			c.emit(OP_FALSE)
+		default:
+			c.emitShort(OP_CONSTANT, c.makeConstant(e.value))
+		}
+	case *Logical:
+		c.expression(e.left)
+		c.token = e.operator
+		if e.operator.l_type == OR {
+			elseJump := c.emitJump(OP_JUMP_IF_FALSE)
+			endJump := c.emitJump(OP_JUMP)
+			c.patchJump(elseJump)
+			c.emit(OP_POP)
+			c.expression(e.right)
+			c.patchJump(endJump)
+		} else {
+			endJump := c.emitJump(OP_JUMP_IF_FALSE)
+			c.emit(OP_POP)
+			c.expression(e.right)
+			c.patchJump(endJump)
+		}
+	case *Map:
+		for n := range e.keys {
+			c.expression(e.keys[n])
+			c.expression(e.values[n])
+		}
+		c.token = e.brace
+		c.emitShort(OP_MAP, c.count(len(e.keys), "Too many entries in a map literal."))
+	case *Set:
+		c.expression(e.object)
+		c.expression(e.value)
+		c.token = e.name
+		c.emitShort(OP_SET_PROPERTY, c.identifier(e.name.lexeme))
+	case *SetIndex:
+		c.expression(e.object)
+		c.expression(e.index)
+		c.expression(e.value)
+		c.token = e.bracket
+		c.emit(OP_SET_INDEX)
+	case *Super:
+		this := e.keyword
+		this.lexeme = "this"
+		c.variable(this, false)
+		c.variable(e.keyword, false)
+		c.token = e.method
+		c.emitShort(OP_GET_SUPER, c.identifier(e.method.lexeme))
+	case *This:
+		c.variable(e.keyword, false)
+	case *Unary:
+		c.expression(e.right)
+		c.token = e.operator
+		if e.operator.l_type == BANG {
+			c.emit(OP_NOT)
+		} else {
+			c.emit(OP_NEGATE)
+		}
+	case *Variable:
+		c.variable(e.name, false)
+	}
+}
+
+// variable reads a variable, or assigns it the value on top of the stack
+func (c *compiler) variable(name Token, assign bool) {
+	c.token = name
+	if slot := c.resolveLocal(name.lexeme); slot != -1 {
+		if assign {
+			c.emitShort(OP_SET_LOCAL, slot)
+		} else {
+			c.emitShort(OP_GET_LOCAL, slot)
+		}
+	} else if upvalue := c.resolveUpvalue(name.lexeme); upvalue != -1 {
+		if assign {
+			c.emitShort(OP_SET_UPVALUE, upvalue)
+		} else {
+			c.emitShort(OP_GET_UPVALUE, upvalue)
+		}
+	} else if assign {
+		c.emitShort(OP_SET_GLOBAL, c.identifier(name.lexeme))
+	} else {
+		c.emitShort(OP_GET_GLOBAL, c.identifier(name.lexeme))
+	}
+}
+
+// defineGlobal turns the value on top of the stack into a global, if we're at the top level
+func (c *compiler) defineGlobal(name Token) {
+	if c.scopeDepth == 0 {
+		c.emitShort(OP_DEFINE_GLOBAL, c.identifier(name.lexeme))
+	}
+}
+
+func (c *compiler) resolveLocal(name string) int {
+	for n := len(c.locals) - 1; n >= 0; n-- {
+		if c.locals[n].name == name && !c.isHidden(n) {
+			return n
+		}
+	}
+	return -1
+}
+
+func (c *compiler) isHidden(slot int) bool {
+	for _, r := range c.hidden {
+		if slot >= r.start && slot < r.end {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiler) resolveUpvalue(name string) int {
+	if c.enclosing == nil {
+		return -1
+	}
+	if slot := c.enclosing.resolveLocal(name); slot != -1 {
+		c.enclosing.locals[slot].captured = true
+		return c.addUpvalue(slot, true)
+	}
+	if upvalue := c.enclosing.resolveUpvalue(name); upvalue != -1 {
+		return c.addUpvalue(upvalue, false)
+	}
+	return -1
+}
+
+func (c *compiler) addUpvalue(index int, isLocal bool) int {
+	for n, upvalue := range c.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return n
+		}
+	}
+	c.upvalues = append(c.upvalues, upvalueRef{index, isLocal})
+	return c.count(len(c.upvalues)-1, "Too many closure variables in function.")
+}
+
+// addLocal makes the value on top of the stack a local variable
+func (c *compiler) addLocal(name string) {
+	c.count(len(c.locals), "Too many local variables in function.")
+	c.locals = append(c.locals, local{name: name, depth: c.scopeDepth})
+}
+
+func (c *compiler) beginScope() {
+	c.scopeDepth++
+}
+
+func (c *compiler) endScope() {
+	c.scopeDepth--
+	c.popLocals(c.scopeDepth)
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+// popLocals emits the code to discard the locals deeper than depth, leaving the compiler's own list alone
+func (c *compiler) popLocals(depth int) {
+	for n := len(c.locals) - 1; n >= 0 && c.locals[n].depth > depth; n-- {
+		if c.locals[n].captured {
+			c.emit(OP_CLOSE_UPVALUE)
+		} else {
+			c.emit(OP_POP)
+		}
+	}
+}
+
+func (c *compiler) emit(op OpCode) {
+	c.function.chunk.write(byte(op), c.token)
+}
+
+func (c *compiler) emitShort(op OpCode, operand int) {
+	c.emit(op)
+	c.writeShort(operand)
+}
+
+func (c *compiler) writeShort(n int) {
+	c.function.chunk.write(byte(n>>8), c.token)
+	c.function.chunk.write(byte(n), c.token)
+}
+
+func (c *compiler) emitReturn() {
+	if c.kind == functiontype.INITIALIZER {
+		c.emitShort(OP_GET_LOCAL, 0)
+	} else {
+		c.emit(OP_NIL)
+	}
+	c.emit(OP_RETURN)
+}
+
+// emitJump emits a jump with a placeholder offset and returns where the offset goes, for patchJump
+func (c *compiler) emitJump(op OpCode) int {
+	c.emitShort(op, maxOperand)
+	return len(c.function.chunk.code) - 2
+}
+
+// emitHandler pushes a handler that resets the stack to the given number of locals before it runs
+func (c *compiler) emitHandler(locals int) int {
+	c.emitShort(OP_PUSH_HANDLER, locals)
+	c.writeShort(maxOperand)
+	return len(c.function.chunk.code) - 2
+}
+
+func (c *compiler) patchJump(offset int) {
+	code := c.function.chunk.code
+	jump := c.count(len(code)-offset-2, "Too much code to jump over.")
+	code[offset] = byte(jump >> 8)
+	code[offset+1] = byte(jump)
+}
+
+func (c *compiler) emitLoop(start int) {
+	c.emit(OP_LOOP)
+	c.writeShort(c.count(len(c.function.chunk.code)-start+2, "Loop body too large."))
+}
+
+func (c *compiler) makeConstant(value any) int {
+	return c.count(c.function.chunk.addConstant(value), "Too many constants in one chunk.")
+}
+
+func (c *compiler) identifier(name string) int {
+	if index, ok := c.names[name]; ok {
+		return index
+	}
+	index := c.makeConstant(name)
+	c.names[name] = index
+	return index
+}
+
+// count reports an error if n doesn't fit in an operand
+func (c *compiler) count(n int, message string) int {
+	if n > maxOperand {
+		c.diagnostics.tokenError(CompileDiagnostic, c.token, message)
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,187 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type DiagnosticKind int
+
+const (
+	ScanDiagnostic DiagnosticKind = iota
+	ParseDiagnostic
+	ResolveDiagnostic
+	RuntimeDiagnostic
+	// Limits of the bytecode format, found while compiling for the VM
+	CompileDiagnostic
+	// Suspicious code that still runs, like unused variables. Warnings aren't errors.
+	WarningDiagnostic
+)
+
+func (k DiagnosticKind) String() string {
+	switch k {
+	case ScanDiagnostic:
+		return "scan"
+	case ParseDiagnostic:
+		return "parse"
+	case ResolveDiagnostic:
+		return "resolve"
+	case RuntimeDiagnostic:
+		return "runtime"
+	case CompileDiagnostic:
+		return "compile"
+	case WarningDiagnostic:
+		return "warning"
+	}
+	return fmt.Sprintf("DiagnosticKind(%d)", int(k))
+}
+
+// Diagnostic is a single problem found while running a program.
+type Diagnostic struct {
+	Kind    DiagnosticKind
+	Line    int
+	Column  int
+	Offset  int
+	Length  int
+	Lexeme  string
+	Message string
+	// Only runtime errors have a traceback, innermost frame first
+	Trace   []StackFrame
+	atEnd   bool
+	excerpt string
+	token   Token
+}
+
+// String formats the diagnostic the way the CLI prints it, followed by the offending source line.
+func (d Diagnostic) String() string {
+	var s string
+	switch {
+	case d.Kind == RuntimeDiagnostic:
+		return formatTraceback(d.Message, d.token, d.Trace)
+	case d.Kind == WarningDiagnostic:
+		s = fmt.Sprintf("[line %d] Warning at '%s': %s", d.Line, d.Lexeme, d.Message)
+	case d.atEnd:
+		s = fmt.Sprintf("[line %d] Error at end: %s", d.Line, d.Message)
+	case d.Kind == ScanDiagnostic:
+		s = fmt.Sprintf("[line %d] Error: %s", d.Line, d.Message)
+	default:
+		s = fmt.Sprintf("[line %d] Error at '%s': %s", d.Line, d.Lexeme, d.Message)
+	}
+	if d.excerpt != "" {
+		s += "\n" + d.excerpt
+	}
+	return s
+}
+
+func newDiagnostic(kind DiagnosticKind, token Token, message string) Diagnostic {
+	return Diagnostic{
+		Kind:    kind,
+		Line:    token.line,
+		Column:  token.column,
+		Offset:  token.start,
+		Length:  token.length,
+		Lexeme:  token.lexeme,
+		Message: message,
+		atEnd:   token.l_type == EOF,
+		excerpt: token.excerpt(),
+		token:   token,
+	}
+}
+
+/*
+Diagnostics collects every problem found during a single run.
+Each diagnostic is written to out as soon as it is reported, so
+output stays interleaved with anything the program printed.
+
+It is returned from Run as an error when anything went wrong.
+*/
+type Diagnostics struct {
+	out             io.Writer
+	diagnostics     []Diagnostic
+	runtimeErr      error
+	hadError        bool
+	hadRuntimeError bool
+	// Warnings wait here until flushWarnings, since the passes that find them each go through the source separately
+	warnings []Diagnostic
+}
+
+func NewDiagnostics(out io.Writer) *Diagnostics {
+	return &Diagnostics{out: out}
+}
+
+// All returns everything reported so far, in order.
+func (d *Diagnostics) All() []Diagnostic {
+	return d.diagnostics
+}
+
+// HadError reports whether a scan, parse or resolve error was found.
+func (d *Diagnostics) HadError() bool {
+	return d.hadError
+}
+
+// HadRuntimeError reports whether the program stopped with a runtime error.
+func (d *Diagnostics) HadRuntimeError() bool {
+	return d.hadRuntimeError
+}
+
+func (d *Diagnostics) Error() string {
+	lines := make([]string, len(d.diagnostics))
+	for i, diag := range d.diagnostics {
+		lines[i] = diag.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Is lets errors.Is(err, ErrCompile) detect static errors.
+func (d *Diagnostics) Is(target error) bool {
+	return target == ErrCompile && d.hadError
+}
+
+// Unwrap returns the error that stopped the interpreter, if any.
+func (d *Diagnostics) Unwrap() error {
+	return d.runtimeErr
+}
+
+func (d *Diagnostics) add(diag Diagnostic) {
+	d.diagnostics = append(d.diagnostics, diag)
+	fmt.Fprintln(d.out, diag)
+}
+
+func (d *Diagnostics) scanError(token Token, message string) {
+	d.hadError = true
+	d.add(newDiagnostic(ScanDiagnostic, token, message))
+}
+
+func (d *Diagnostics) tokenError(kind DiagnosticKind, token Token, message string) {
+	d.hadError = true
+	d.add(newDiagnostic(kind, token, message))
+}
+
+// warning records a problem that doesn't stop the program from running
+func (d *Diagnostics) warning(token Token, message string) {
+	d.warnings = append(d.warnings, newDiagnostic(WarningDiagnostic, token, message))
+}
+
+// flushWarnings reports the warnings recorded so far, in the order they appear in the source
+func (d *Diagnostics) flushWarnings() {
+	sort.SliceStable(d.warnings, func(i, j int) bool { return d.warnings[i].Offset < d.warnings[j].Offset })
+	for _, warning := range d.warnings {
+		d.add(warning)
+	}
+	d.warnings = nil
+}
+
+func (d *Diagnostics) runtimeError(e error) {
+	d.hadRuntimeError = true
+	d.runtimeErr = e
+	if re, ok := e.(*RuntimeError); ok {
+		diag := newDiagnostic(RuntimeDiagnostic, re.token, re.err)
+		diag.Trace = re.trace
+		d.add(diag)
+		return
+	}
+	d.diagnostics = append(d.diagnostics, Diagnostic{Kind: RuntimeDiagnostic, Message: e.Error()})
+	fmt.Fprintln(d.out, e)
+}
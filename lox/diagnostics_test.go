@@ -0,0 +1,60 @@
+package lox
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestRunReturnsStructuredErrors(t *testing.T) {
+	l := New(WithStderr(io.Discard))
+	err := l.Run("var a = 1;\nprint a +;")
+
+	if !errors.Is(err, ErrCompile) {
+		t.Fatalf("Run() returned %v, expected a compile error", err)
+	}
+	var d *Diagnostics
+	if !errors.As(err, &d) {
+		t.Fatalf("Run() returned %T, expected *Diagnostics", err)
+	}
+	got := d.All()
+	if len(got) != 1 {
+		t.Fatalf("got %d diagnostics, expected 1: %v", len(got), got)
+	}
+	expected := Diagnostic{Kind: ParseDiagnostic, Line: 2, Column: 10, Offset: 20, Length: 1, Lexeme: ";", Message: "Expect expression."}
+	expectedExcerpt := "   2 | print a +;\n     |          ^"
+	if got[0].excerpt != expectedExcerpt {
+		t.Errorf("got excerpt\n%s\nexpected\n%s", got[0].excerpt, expectedExcerpt)
+	}
+	got[0].excerpt = ""
+	got[0].token = Token{}
+	if !reflect.DeepEqual(got[0], expected) {
+		t.Errorf("got diagnostic %#v, expected %#v", got[0], expected)
+	}
+}
+
+func TestRuntimeErrorUnwraps(t *testing.T) {
+	l := New(WithStderr(io.Discard))
+	err := l.Run("print -\"a\";")
+
+	var re *RuntimeError
+	if !errors.As(err, &re) {
+		t.Fatalf("Run() returned %v, expected a *RuntimeError", err)
+	}
+	if errors.Is(err, ErrCompile) {
+		t.Errorf("runtime error matched ErrCompile")
+	}
+}
+
+func TestDiagnosticsAreIndependent(t *testing.T) {
+	bad := New(WithStderr(io.Discard))
+	good := New(WithStderr(io.Discard))
+
+	if err := bad.Run("print ;"); err == nil {
+		t.Fatalf("expected an error from the bad program")
+	}
+	if err := good.Run("var a = 1;"); err != nil {
+		t.Errorf("error from one interpreter leaked into another: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+)
+
+// disassemble prints a function's bytecode, followed by the functions defined in it
+func disassemble(w io.Writer, f *vmFunction) {
+	fmt.Fprintf(w, "== %s ==\n", f)
+	for offset := 0; offset < len(f.chunk.code); {
+		offset = disassembleInstruction(w, &f.chunk, offset)
+	}
+	for _, constant := range f.chunk.constants {
+		if function, ok := constant.(*vmFunction); ok {
+			fmt.Fprintln(w)
+			disassemble(w, function)
+		}
+	}
+}
+
+// disassembleInstruction prints the instruction at offset and returns where the next one starts
+func disassembleInstruction(w io.Writer, c *chunk, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if line := c.tokenAt(offset).line; offset > 0 && line == c.tokenAt(offset-1).line {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", line)
+	}
+
+	op := OpCode(c.code[offset])
+	short := func(at int) int {
+		return int(c.code[at])<<8 | int(c.code[at+1])
+	}
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL, OP_GET_PROPERTY,
+		OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD:
+		constant := short(offset + 1)
+		fmt.Fprintf(w, "%-16s %4d '%s'\n", op, constant, exactString(c.constants[constant]))
+		return offset + 3
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL, OP_LIST, OP_MAP:
+		fmt.Fprintf(w, "%-16s %4d\n", op, short(offset+1))
+		return offset + 3
+	case OP_JUMP, OP_JUMP_IF_FALSE:
+		fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3+short(offset+1))
+		return offset + 3
+	case OP_LOOP:
+		fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3-short(offset+1))
+		return offset + 3
+	case OP_PUSH_HANDLER:
+		fmt.Fprintf(w, "%-16s %4d -> %d\n", op, short(offset+1), offset+5+short(offset+3))
+		return offset + 5
+	case OP_CLOSURE:
+		constant := short(offset + 1)
+		function := c.constants[constant].(*vmFunction)
+		fmt.Fprintf(w, "%-16s %4d %s\n", op, constant, function)
+		offset += 3
+		for n := 0; n < function.upvalueCount; n++ {
+			kind := "upvalue"
+			if c.code[offset] == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(w, "%04d    |                     %s %d\n", offset, kind, short(offset+1))
+			offset += 3
+		}
+		return offset
+	}
+	fmt.Fprintln(w, op)
+	return offset + 1
+}
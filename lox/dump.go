@@ -0,0 +1,91 @@
+package lox
+
+import "fmt"
+
+// The Dump methods show what each stage of the interpreter makes of a program, without running it.
+
+// DumpTokens prints the tokens the scanner finds in source, one per line after its line number.
+func (l *Lox) DumpTokens(source string) error {
+	diagnostics := NewDiagnostics(l.stderr)
+	scanner := NewScanner(source)
+	for _, token := range scanner.ScanTokens(diagnostics) {
+		fmt.Fprintf(l.stdout, "%4d %s\n", token.line, token)
+	}
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	return nil
+}
+
+// DumpAST prints the syntax tree of source as S-expressions, one top-level statement per line.
+func (l *Lox) DumpAST(source string) error {
+	diagnostics := NewDiagnostics(l.stderr)
+	statements := l.parse(source, diagnostics)
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	for _, stmt := range statements {
+		fmt.Fprintln(l.stdout, stmt)
+	}
+	return nil
+}
+
+/*
+DumpResolution prints every variable reference in source, in order, with where
+the resolver found it: how many scopes out and which slot, or global.
+*/
+func (l *Lox) DumpResolution(source string) error {
+	diagnostics := NewDiagnostics(l.stderr)
+	statements := l.parse(source, diagnostics)
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	resolver := NewResolver(&l.interpreter, diagnostics)
+	resolver.recordReferences = true
+	resolver.resolve_stmts(statements)
+	diagnostics.flushWarnings()
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+
+	for _, expr := range resolver.references {
+		var name Token
+		switch e := expr.(type) {
+		case *Assign:
+			name = e.name
+		case *Super:
+			name = e.keyword
+		case *This:
+			name = e.keyword
+		case *Variable:
+			name = e.name
+		}
+		if local, ok := l.interpreter.locals[expr]; ok {
+			fmt.Fprintf(l.stdout, "[line %d] %s: depth %d, slot %d\n", name.line, name.lexeme, local.depth, local.slot)
+		} else {
+			fmt.Fprintf(l.stdout, "[line %d] %s: global\n", name.line, name.lexeme)
+		}
+	}
+	return nil
+}
+
+// Disassemble prints the bytecode the VM would run for source.
+func (l *Lox) Disassemble(source string) error {
+	diagnostics := NewDiagnostics(l.stderr)
+	statements := l.parse(source, diagnostics)
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	resolver := NewResolver(&l.interpreter, diagnostics)
+	resolver.resolve_stmts(statements)
+	diagnostics.flushWarnings()
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	script := compile(statements, diagnostics)
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	disassemble(l.stdout, script)
+	return nil
+}
@@ -0,0 +1,137 @@
+package lox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDumpASTCoversEveryNode(t *testing.T) {
+	source := `var a;
+var b = -1 + 2 * (3 - 4);
+a = !true and b or nil;
+fun f(x, y) { return; }
+class C < D {
+  m() { return super.m(this.p, "s"); }
+}
+c.p = [1, 2][0];
+c[1] = {"k": fun (z) { print z; }};
+for (var i = 0; i < 1; i = i + 1) { if (i) break; else continue; }
+while (false) {}
+try { throw 1; } catch (e) {} finally { print 2; }
+`
+	expected := []string{
+		`(var a)`,
+		`(var b (+ (- 1) (* 2 (group (- 3 4)))))`,
+		`(; (= a (or (and (! true) b) nil)))`,
+		`(fun f (x y) (return))`,
+		`(class C < D (fun m () (return (call (super m) (. this p) "s"))))`,
+		`(; (= (. c p) ([] (list 1 2) 0)))`,
+		`(; (= ([] c 1) (map "k" (fun (z) (print z)))))`,
+		`(block (var i 0) (while (< i 1) (block (if i (break) (continue))) (= i (+ i 1))))`,
+		`(while false (block))`,
+		`(try (block (throw 1)) (catch e) (finally (print 2)))`,
+	}
+
+	var stdout bytes.Buffer
+	if err := New(WithStdout(&stdout)).DumpAST(source); err != nil {
+		t.Fatalf("DumpAST() returned %v", err)
+	}
+	got := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(got) != len(expected) {
+		t.Fatalf("printed %d statements, expected %d:\n%s", len(got), len(expected), stdout.String())
+	}
+	for n := range expected {
+		if got[n] != expected[n] {
+			t.Errorf("statement %d printed as\n%s\nexpected\n%s", n+1, got[n], expected[n])
+		}
+	}
+}
+
+// Numbers are shown as written, not rounded the way print rounds them
+func TestDumpsShowExactNumbers(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New(WithStdout(&stdout))
+	if err := l.DumpAST("print 1.25 + 0.05 * 3.14159;"); err != nil {
+		t.Fatalf("DumpAST() returned %v", err)
+	}
+	if got, expected := stdout.String(), "(print (+ 1.25 (* 0.05 3.14159)))\n"; got != expected {
+		t.Errorf("DumpAST() printed %q, expected %q", got, expected)
+	}
+
+	stdout.Reset()
+	if err := l.Disassemble("print 1.25;"); err != nil {
+		t.Fatalf("Disassemble() returned %v", err)
+	}
+	if got, expected := stdout.String(), "OP_CONSTANT         0 '1.25'"; !strings.Contains(got, expected) {
+		t.Errorf("Disassemble() printed\n%s\nexpected it to contain %q", got, expected)
+	}
+}
+
+func TestDumpResolution(t *testing.T) {
+	source := `var g = 1;
+fun f(a) {
+  var b = a;
+  return fun () { return b + g; };
+}`
+	expected := `[line 3] a: depth 0, slot 0
+[line 4] b: depth 1, slot 1
+[line 4] g: global
+`
+	var stdout bytes.Buffer
+	if err := New(WithStdout(&stdout)).DumpResolution(source); err != nil {
+		t.Fatalf("DumpResolution() returned %v", err)
+	}
+	if got := stdout.String(); got != expected {
+		t.Errorf("printed\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := New(WithStdout(&stdout)).Disassemble("fun f(a) { return a; }\nprint f(1);"); err != nil {
+		t.Fatalf("Disassemble() returned %v", err)
+	}
+	expected := `== <script> ==
+0000    1 OP_CLOSURE          0 <fn f>
+0003    | OP_DEFINE_GLOBAL    1 'f'
+0006    2 OP_GET_GLOBAL       1 'f'
+0009    | OP_CONSTANT         2 '1'
+0012    | OP_CALL             1
+0015    | OP_PRINT
+0016    | OP_NIL
+0017    | OP_RETURN
+
+== <fn f> ==
+0000    1 OP_GET_LOCAL        1
+0003    | OP_RETURN
+0004    | OP_NIL
+0005    | OP_RETURN
+`
+	if got := stdout.String(); got != expected {
+		t.Errorf("printed\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+// Statements and literals don't start with an operator, but their instructions still need a line
+func TestDisassembleLinesEveryInstruction(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := New(WithStdout(&stdout)).Disassemble("var a = 1;\n\nprint 1.25 +\n  0.05;\n2;"); err != nil {
+		t.Fatalf("Disassemble() returned %v", err)
+	}
+	expected := `== <script> ==
+0000    1 OP_CONSTANT         0 '1'
+0003    | OP_DEFINE_GLOBAL    1 'a'
+0006    3 OP_CONSTANT         2 '1.25'
+0009    4 OP_CONSTANT         3 '0.05'
+0012    3 OP_ADD
+0013    | OP_PRINT
+0014    5 OP_CONSTANT         4 '2'
+0017    | OP_POP
+0018    | OP_NIL
+0019    | OP_RETURN
+`
+	if got := stdout.String(); got != expected {
+		t.Errorf("printed\n%s\nexpected\n%s", got, expected)
+	}
+}
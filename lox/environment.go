@@ -1,7 +1,14 @@
-package main
+package lox
 
+/*
+Environment holds the variables of one scope. Globals are looked up by name,
+but the resolver gives every local a slot, so local scopes keep their
+variables in declaration order and are read by index.
+*/
 type Environment struct {
+	// Only the global environment has names
 	values    map[string]any
+	slots     []any
 	enclosing *Environment
 }
 
@@ -12,19 +19,29 @@ func NewEnvironment() Environment {
 	}
 }
 
+// newLocalEnvironment creates the environment for a block, call or other local scope
+func newLocalEnvironment(enclosing *Environment) *Environment {
+	return &Environment{enclosing: enclosing}
+}
+
 /*
-Define a variable in an environment, used with assigning later on
+Define a variable in an environment, used with assigning later on.
+In a local scope it takes the next slot, which is the one the resolver gave it.
 */
 func (e *Environment) define(name string, value any) {
+	if e.values == nil {
+		e.slots = append(e.slots, value)
+		return
+	}
 	e.values[name] = value
 }
 
-func (e *Environment) getAt(distance int, name string) any {
-	return e.ancestor(distance).values[name]
+func (e *Environment) getAt(distance, slot int) any {
+	return e.ancestor(distance).slots[slot]
 }
 
-func (e *Environment) assignAt(distance int, name Token, value any) {
-	e.ancestor(distance).values[name.lexeme] = value
+func (e *Environment) assignAt(distance, slot int, value any) {
+	e.ancestor(distance).slots[slot] = value
 }
 
 func (e *Environment) ancestor(distance int) *Environment {
@@ -50,7 +67,7 @@ func (e *Environment) get(name Token) (any, error) {
 		return e.enclosing.get(name)
 	}
 
-	return nil, RuntimeError{name, "Undefined variable '" + name.lexeme + "'."}
+	return nil, NewRuntimeError(name, "Undefined variable '"+name.lexeme+"'.")
 }
 
 /*
@@ -66,12 +83,11 @@ func (e *Environment) assign(name Token, value any) error {
 		Shadow a variable if one is defined in an upper environment
 	*/
 	if e.enclosing != nil {
-		e.enclosing.assign(name, value)
-		return nil
+		return e.enclosing.assign(name, value)
 	}
 
 	/*
 		Error if variable not defined anywhere
 	*/
-	return RuntimeError{name, "Undefined variable '" + name.lexeme + "'."}
+	return NewRuntimeError(name, "Undefined variable '"+name.lexeme+"'.")
 }
@@ -1,4 +1,4 @@
-package main
+package lox
 
 type Expr interface {
 	Expression() Expr
@@ -30,7 +30,25 @@ type Grouping struct {
 	expression Expr
 }
 
+type Index struct {
+	object  Expr
+	bracket Token
+	index   Expr
+}
+
+type Lambda struct {
+	keyword Token
+	params  []Token
+	body    []Stmt
+}
+
+type List struct {
+	bracket  Token
+	elements []Expr
+}
+
 type Literal struct {
+	token Token
 	value any
 }
 
@@ -40,12 +58,25 @@ type Logical struct {
 	right    Expr
 }
 
+type Map struct {
+	brace  Token
+	keys   []Expr
+	values []Expr
+}
+
 type Set struct {
 	object Expr
 	name   Token
 	value  Expr
 }
 
+type SetIndex struct {
+	object  Expr
+	bracket Token
+	index   Expr
+	value   Expr
+}
+
 type Super struct {
 	keyword Token
 	method  Token
@@ -74,12 +105,22 @@ func (e *Get) Expression() Expr { return e }
 
 func (e *Grouping) Expression() Expr { return e }
 
+func (e *Index) Expression() Expr { return e }
+
+func (e *Lambda) Expression() Expr { return e }
+
+func (e *List) Expression() Expr { return e }
+
 func (e *Literal) Expression() Expr { return e }
 
 func (e *Logical) Expression() Expr { return e }
 
+func (e *Map) Expression() Expr { return e }
+
 func (e *Set) Expression() Expr { return e }
 
+func (e *SetIndex) Expression() Expr { return e }
+
 func (e *Super) Expression() Expr { return e }
 
 func (e *This) Expression() Expr { return e }
@@ -0,0 +1,649 @@
+package lox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type interpreter struct {
+	globals     Environment
+	environment *Environment
+	locals      map[Expr]resolvedLocal
+	stdout      io.Writer
+	stdin       *programInput
+	frames      []callFrame
+	// The paren of the call being made, for the frame the callee pushes
+	callSite Token
+}
+
+// Built-in clock functionality
+type clock struct{}
+
+func (c clock) arity() int {
+	return 0
+}
+
+func (c clock) call(int *interpreter, args []any) (any, error) {
+	return float64(time.Now().UnixMilli() / 1000), nil
+}
+
+func (c clock) String() string {
+	return "<fn clock>"
+}
+
+func NewInterpreter() *interpreter {
+	global := NewEnvironment()
+	env := &global
+
+	global.define("clock", clock{})
+	defineNatives(&global, stringNatives)
+	defineMath(&global)
+	return &interpreter{
+		globals:     global,
+		environment: env,
+		locals:      make(map[Expr]resolvedLocal),
+		stdout:      os.Stdout,
+		stdin:       &programInput{in: bufio.NewReader(os.Stdin)},
+	}
+}
+
+func (i *interpreter) interpret(statements []Stmt) error {
+	for _, s := range statements {
+		err := i.execute(s)
+		if err != nil {
+			return i.traced(err)
+		}
+	}
+	return nil
+}
+
+// Visit statement replacement
+func (i *interpreter) execute(stmt Stmt) error {
+	switch t := stmt.(type) {
+	case *Block:
+		err := i.executeBlock(t.statements, i.newScope())
+		if err != nil {
+			return err
+		}
+	case *Class:
+		var superclass any
+		if t.superclass != (Variable{}) {
+			var err error
+			superclass, err = i.evaluate(&t.superclass)
+			if err != nil {
+				return err
+			}
+			if _, ok := superclass.(LoxClass); !ok {
+				return NewRuntimeError(t.superclass.name, "Superclass must be a class.")
+			}
+		}
+		if t.superclass != (Variable{}) {
+			i.environment = newLocalEnvironment(i.environment)
+			i.environment.define("super", superclass)
+		}
+
+		methods := make(map[string]LoxFunction)
+		for _, method := range t.methods {
+			function := NewLoxFunction(method, i.environment, method.name.lexeme == "init")
+			function.className = t.name.lexeme
+			methods[method.name.lexeme] = function
+		}
+		var c LoxClass
+		if sc, ok := superclass.(LoxClass); ok {
+			c = NewLoxClass(t.name.lexeme, &sc, methods)
+		} else {
+			c = NewLoxClass(t.name.lexeme, nil, methods)
+		}
+
+		if t.superclass != (Variable{}) {
+			i.environment = i.environment.enclosing
+		}
+
+		// Methods only look the class up once they're called, by which point it's defined
+		i.environment.define(t.name.lexeme, c)
+	case *Expression:
+		_, err := i.evaluate(t.expression)
+		if err != nil {
+			return err
+		}
+	case *Function:
+		function := NewLoxFunction(*t, i.environment, false)
+		i.environment.define(t.name.lexeme, function)
+	case *If:
+		cond, err := i.evaluate(t.condition)
+		if err != nil {
+			return err
+		}
+		if isTruthy(cond) {
+			err := i.execute(t.thenBranch)
+			if err != nil {
+				return err
+			}
+		} else if t.elseBranch != nil {
+			err := i.execute(t.elseBranch)
+			if err != nil {
+				return err
+			}
+		}
+	case *Print:
+		value, err := i.evaluate(t.expression)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(i.stdout, stringify(value))
+	case *Break:
+		return &BreakError{}
+	case *Continue:
+		return &ContinueError{}
+	case *Return:
+		var value any
+		var err error
+		if t.value != nil {
+			value, err = i.evaluate(t.value)
+			if err != nil {
+				return err
+			}
+		}
+		return NewReturnError(value)
+	case *Throw:
+		value, err := i.evaluate(t.value)
+		if err != nil {
+			return err
+		}
+		return NewThrowError(t.keyword, value)
+	case *Try:
+		err := i.executeBlock(t.tryBranch, i.newScope())
+		if re, ok := err.(*RuntimeError); ok && t.catchName.lexeme != "" {
+			env := i.newScope()
+			env.define(t.catchName.lexeme, re.loxValue())
+			err = i.executeBlock(t.catchBranch, env)
+		}
+		// Runs no matter how the try or catch finished, including a return, break or continue
+		if len(t.finallyBranch) > 0 {
+			finallyErr := i.executeBlock(t.finallyBranch, i.newScope())
+			if finallyErr != nil {
+				return finallyErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	case *While:
+		for {
+			cond, err := i.evaluate(t.condition)
+			if err != nil {
+				return err
+			}
+			if !isTruthy(cond) {
+				break
+			}
+
+			err = i.execute(t.body)
+			if _, ok := err.(*BreakError); ok {
+				break
+			}
+			if _, ok := err.(*ContinueError); err != nil && !ok {
+				return err
+			}
+
+			// Desugared for loops keep their increment here so it runs after a continue
+			if t.increment != nil {
+				_, err = i.evaluate(t.increment)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case *Var:
+		var value any
+		var err error
+		if t.initializer != nil {
+			value, err = i.evaluate(t.initializer)
+			if err != nil {
+				return err
+			}
+		} else {
+			value = nil
+		}
+		i.environment.define(t.name.lexeme, value)
+	}
+	return nil
+}
+
+// resolvedLocal is where the resolver found a local variable: how many scopes out, and which slot in that scope
+type resolvedLocal struct {
+	depth int
+	slot  int
+}
+
+func (i *interpreter) resolve(expr Expr, depth, slot int) {
+	i.locals[expr] = resolvedLocal{depth, slot}
+}
+
+// newScope creates an environment for a block nested in the current one
+func (i *interpreter) newScope() *Environment {
+	return newLocalEnvironment(i.environment)
+}
+
+func (i *interpreter) executeBlock(statements []Stmt, env *Environment) error {
+	previous := i.environment
+	// Mimic "finally" block
+	defer func() { i.environment = previous }()
+
+	i.environment = env
+	for _, stmt := range statements {
+		err := i.execute(stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Visit Expression replacement
+func (i *interpreter) evaluate(expr Expr) (any, error) {
+	switch e := expr.(type) {
+	case *Assign:
+		value, err := i.evaluate(e.value)
+		if err != nil {
+			return nil, err
+		}
+		if local, ok := i.locals[expr]; ok {
+			i.environment.assignAt(local.depth, local.slot, value)
+		} else {
+			err = i.globals.assign(e.name, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return value, nil
+	case *Call:
+		callee, err := i.evaluate(e.callee)
+		if err != nil {
+			return nil, err
+		}
+		var arguments []any
+		for _, arg := range e.arguments {
+			eval, err := i.evaluate(arg)
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, eval)
+		}
+
+		// Don't allow trying to call "foobar"()
+		local_func, ok := callee.(LoxCallable)
+		if !ok {
+			return nil, NewRuntimeError(e.paren, "Can only call functions and classes.")
+		}
+
+		// Check arity of the function
+		if local_func.arity() != variadic && len(arguments) != local_func.arity() {
+			return nil, NewRuntimeError(e.paren, fmt.Sprintf("Expected %d arguments but got %d.", local_func.arity(), len(arguments)))
+		}
+		i.callSite = e.paren
+		ret, err := local_func.call(i, arguments)
+		if ne, ok := err.(*nativeError); ok {
+			return nil, NewRuntimeError(e.paren, ne.message)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	case *Get:
+		object, err := i.evaluate(e.object)
+		if err != nil {
+			return nil, err
+		}
+		if o, ok := object.(LoxInstance); ok {
+			return o.get(e.name)
+		} else if l, ok := object.(*LoxList); ok {
+			return l.get(e.name)
+		} else if m, ok := object.(*LoxMap); ok {
+			return m.get(e.name)
+		} else if le, ok := object.(*LoxError); ok {
+			return le.get(e.name)
+		} else {
+			return nil, NewRuntimeError(e.name, "Only instances have properties.")
+		}
+	case *Grouping:
+		return i.evaluate(e.expression)
+	case *Index:
+		object, err := i.evaluate(e.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := i.evaluate(e.index)
+		if err != nil {
+			return nil, err
+		}
+		if l, ok := object.(*LoxList); ok {
+			return l.getIndex(e.bracket, index)
+		} else if m, ok := object.(*LoxMap); ok {
+			return m.getIndex(e.bracket, index)
+		}
+		return nil, NewRuntimeError(e.bracket, "Only lists and maps can be indexed.")
+	case *Lambda:
+		return NewLoxFunction(Function{e.keyword, e.params, e.body}, i.environment, false), nil
+	case *List:
+		elements := make([]any, 0, len(e.elements))
+		for _, element := range e.elements {
+			value, err := i.evaluate(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, value)
+		}
+		return NewLoxList(elements), nil
+	case *Map:
+		m := NewLoxMap()
+		for n := range e.keys {
+			key, err := i.evaluate(e.keys[n])
+			if err != nil {
+				return nil, err
+			}
+			value, err := i.evaluate(e.values[n])
+			if err != nil {
+				return nil, err
+			}
+			err = m.setIndex(e.brace, key, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case *Literal:
+		return e.value, nil
+	case *Logical:
+		left, err := i.evaluate(e.left)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.operator.l_type == OR {
+			// Short circuit true for OR
+			if isTruthy(left) {
+				return left, nil
+			}
+		} else {
+			// Short circuit false for AND
+			if !isTruthy(left) {
+				return left, nil
+			}
+		}
+		return i.evaluate(e.right)
+	case *Set:
+		obj, err := i.evaluate(e.object)
+		if err != nil {
+			return nil, err
+		}
+		if o, ok := obj.(LoxInstance); ok {
+			value, err := i.evaluate(e.value)
+			if err != nil {
+				return nil, err
+			}
+			o.set(e.name, value)
+			return value, nil
+		} else {
+			return nil, NewRuntimeError(e.name, "Only instances have fields.")
+		}
+	case *SetIndex:
+		object, err := i.evaluate(e.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := i.evaluate(e.index)
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(e.value)
+		if err != nil {
+			return nil, err
+		}
+		if l, ok := object.(*LoxList); ok {
+			return value, l.setIndex(e.bracket, index, value)
+		} else if m, ok := object.(*LoxMap); ok {
+			return value, m.setIndex(e.bracket, index, value)
+		}
+		return nil, NewRuntimeError(e.bracket, "Only lists and maps can be indexed.")
+	case *Super:
+		local := i.locals[e]
+		sc := i.environment.getAt(local.depth, local.slot).(LoxClass)
+		// We know this is always 1 away from super, and alone in its scope
+		object := i.environment.getAt(local.depth-1, 0).(LoxInstance)
+
+		method, err := sc.findMethod(e.method.lexeme)
+		if err != nil {
+			return nil, NewRuntimeError(e.method, fmt.Sprintf("Undefined property %s.", e.method.lexeme))
+		}
+		return method.bind(object), nil
+	case *This:
+		return i.lookUpVariable(e.keyword, e)
+	case *Unary:
+		right, err := i.evaluate(e.right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch e.operator.l_type {
+		case BANG:
+			return !isTruthy(right), nil
+		case MINUS:
+			r, err := i.checkNumberOperand(e.operator, right)
+			if err != nil {
+				return nil, err
+			}
+			return -r, nil
+		}
+		// Unreachable
+		return nil, errors.New("unreachable code error")
+	case *Binary:
+		left, err := i.evaluate(e.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := i.evaluate(e.right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch e.operator.l_type {
+		case GREATER:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l > r, nil
+		case GREATER_EQUAL:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l >= r, nil
+		case LESS:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l < r, nil
+		case LESS_EQUAL:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l <= r, nil
+		case BANG_EQUAL:
+			return !isEqual(left, right), nil
+		case EQUAL_EQUAL:
+			return isEqual(left, right), nil
+		case MINUS:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l - r, nil
+		case PLUS:
+			if l, ok := left.(float64); ok {
+				if r, ok := right.(float64); ok {
+					return l + r, nil
+				}
+			} else if l, ok := left.(string); ok {
+				if r, ok := right.(string); ok {
+					return l + r, nil
+				}
+			}
+			return nil, NewRuntimeError(e.operator, "operands must be two numbers or two strings.")
+		case SLASH:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l / r, nil
+		case STAR:
+			l, r, err := i.checkNumberOperands(e.operator, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return l * r, nil
+		}
+		// Unreachable
+		return nil, errors.New("unreachable code error")
+	case *Variable:
+		return i.lookUpVariable(e.name, e)
+	}
+	return nil, errors.New("unreachable code error")
+}
+
+func (i *interpreter) lookUpVariable(name Token, expr Expr) (any, error) {
+	if local, ok := i.locals[expr]; ok {
+		return i.environment.getAt(local.depth, local.slot), nil
+	}
+	return i.globals.get(name)
+
+}
+
+func isTruthy(obj any) bool {
+	if obj == nil {
+		return false
+	}
+	if _, ok := obj.(bool); ok {
+		return obj.(bool)
+	}
+	return true
+}
+
+func isEqual(a, b any) bool {
+	if a == nil && b == nil {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	// Objects are copied around by value, so compare them by what the copies share
+	switch a := a.(type) {
+	case LoxInstance:
+		b, ok := b.(LoxInstance)
+		return ok && sameMap(a.fields, b.fields)
+	case LoxClass:
+		b, ok := b.(LoxClass)
+		return ok && sameMap(a.methods, b.methods)
+	case LoxFunction:
+		// Each closure is a different function, even over the same declaration
+		b, ok := b.(LoxFunction)
+		return ok && a.declaration.name == b.declaration.name && a.closure == b.closure
+	case nativeFunction:
+		b, ok := b.(nativeFunction)
+		return ok && a.name == b.name && reflect.ValueOf(a.fn).Pointer() == reflect.ValueOf(b.fn).Pointer()
+	}
+	return a == b
+}
+
+// sameMap reports whether a and b are the same map, rather than two with the same contents
+func sameMap(a, b any) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func (i *interpreter) checkNumberOperand(operator Token, operand any) (float64, error) {
+	o, ok := operand.(float64)
+	if !ok {
+		return 0, NewRuntimeError(operator, "operand must be a number.")
+	}
+	return o, nil
+}
+
+func (i *interpreter) checkNumberOperands(operator Token, left, right any) (float64, float64, error) {
+	l, ok := left.(float64)
+	r, ok2 := right.(float64)
+	if !ok || !ok2 {
+		return 0, 0, NewRuntimeError(operator, "operands must be a number.")
+	}
+	return l, r, nil
+}
+
+func stringify(object any) string {
+	if object == nil {
+		return "nil"
+	}
+
+	switch o := object.(type) {
+	case *LoxList, *LoxMap:
+		return stringifyContainer(o, map[any]bool{})
+	}
+
+	if reflect.TypeOf(object).Kind().String() == "float64" {
+		text := fmt.Sprintf("%.1f", object)
+		if text[len(text)-2:] == ".0" {
+			text = text[:len(text)-2]
+		}
+		return text
+	}
+	return fmt.Sprintf("%+v", object)
+
+}
+
+// stringifyElement formats a value inside a list or map, where strings are quoted
+func stringifyElement(element any, seen map[any]bool) string {
+	switch e := element.(type) {
+	case string:
+		return strconv.Quote(e)
+	case *LoxList, *LoxMap:
+		return stringifyContainer(e, seen)
+	}
+	return stringify(element)
+}
+
+// stringifyContainer prints a list or map, using [...] or {...} for one that contains itself
+func stringifyContainer(container any, seen map[any]bool) string {
+	if seen[container] {
+		if _, ok := container.(*LoxList); ok {
+			return "[...]"
+		}
+		return "{...}"
+	}
+	seen[container] = true
+	defer delete(seen, container)
+
+	switch c := container.(type) {
+	case *LoxList:
+		elements := make([]string, len(c.elements))
+		for n, element := range c.elements {
+			elements[n] = stringifyElement(element, seen)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *LoxMap:
+		entries := make([]string, len(c.keys))
+		for n, key := range c.keys {
+			entries[n] = stringifyElement(key, seen) + ": " + stringifyElement(c.values[key], seen)
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	return stringify(container)
+}
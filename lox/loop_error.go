@@ -0,0 +1,15 @@
+package lox
+
+// BreakError unwinds out of the innermost loop, like ReturnError does for functions
+type BreakError struct{}
+
+func (e BreakError) Error() string {
+	return "Break error encountered"
+}
+
+// ContinueError unwinds to the end of the innermost loop's body
+type ContinueError struct{}
+
+func (e ContinueError) Error() string {
+	return "Continue error encountered"
+}
@@ -0,0 +1,223 @@
+package lox
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrCompile is returned by Run, RunFile and Eval when the source could not be
+// scanned, parsed or resolved. The individual problems have already been reported.
+var ErrCompile = errors.New("lox: compile error")
+
+// Lox is an embeddable interpreter. Globals defined by one call to Run are
+// visible to later calls on the same Lox.
+type Lox struct {
+	interpreter interpreter
+	stdout      io.Writer
+	stderr      io.Writer
+	stdin       io.Reader
+	// Where the REPL reads its lines, kept apart from the programs' input
+	promptInput io.Reader
+	files       sandbox
+	// Set when programs run on the bytecode VM rather than the tree-walking interpreter
+	vm       *vm
+	bytecode bool
+	// Names of the globals every Lox starts with, which the REPL's :env leaves out
+	builtins map[string]bool
+}
+
+// Option configures a Lox created by New.
+type Option func(*Lox)
+
+// WithStdout sends the output of print statements to w instead of os.Stdout.
+func WithStdout(w io.Writer) Option {
+	return func(l *Lox) {
+		l.stdout = w
+	}
+}
+
+// WithStderr sends error reports to w instead of os.Stderr.
+func WithStderr(w io.Writer) Option {
+	return func(l *Lox) {
+		l.stderr = w
+	}
+}
+
+// WithStdin makes readLine and readAll read from r instead of os.Stdin.
+func WithStdin(r io.Reader) Option {
+	return func(l *Lox) {
+		l.stdin = r
+	}
+}
+
+// WithPromptInput makes RunPrompt read the lines typed at the prompt from r instead of os.Stdin.
+func WithPromptInput(r io.Reader) Option {
+	return func(l *Lox) {
+		l.promptInput = r
+	}
+}
+
+/*
+WithFileAccess lets programs use readFile, writeFile, appendFile, listDir and
+fileExists on files under root. Without it those natives raise a runtime error.
+*/
+func WithFileAccess(root string) Option {
+	return func(l *Lox) {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		l.files = sandbox{root}
+	}
+}
+
+// WithBytecode compiles programs to bytecode and runs them on a VM, which is faster than walking the AST.
+func WithBytecode() Option {
+	return func(l *Lox) {
+		l.bytecode = true
+	}
+}
+
+func New(options ...Option) *Lox {
+	l := &Lox{
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		stdin:       os.Stdin,
+		promptInput: os.Stdin,
+	}
+	for _, option := range options {
+		option(l)
+	}
+	l.reset()
+	return l
+}
+
+// reset forgets everything programs have defined, leaving only the built-in globals
+func (l *Lox) reset() {
+	// Keep reading stdin from where the last program got to
+	stdin := l.interpreter.stdin
+	if stdin == nil {
+		stdin = &programInput{in: bufio.NewReader(l.stdin)}
+	}
+	l.interpreter = *NewInterpreter()
+	l.interpreter.stdout = l.stdout
+	l.interpreter.stdin = stdin
+	defineNatives(&l.interpreter.globals, fileNatives(l.files))
+	defineNatives(&l.interpreter.globals, inputNatives(l.interpreter.stdin))
+	l.builtins = make(map[string]bool)
+	for name := range l.interpreter.globals.values {
+		l.builtins[name] = true
+	}
+	l.vm = nil
+	if l.bytecode {
+		// Both backends share one set of globals, natives included
+		l.vm = newVM(l.interpreter.globals.values, l.stdout)
+	}
+}
+
+// RunFile reads the script at path and runs it.
+func (l *Lox) RunFile(path string) error {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return l.Run(string(f))
+}
+
+// Run executes source as a Lox program. If anything goes wrong it returns the
+// run's *Diagnostics, which matches ErrCompile for static errors and unwraps
+// to the *RuntimeError that stopped the program.
+func (l *Lox) Run(source string) error {
+	diagnostics := NewDiagnostics(l.stderr)
+
+	statements := l.parse(source, diagnostics)
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+	return l.execute(statements, diagnostics)
+}
+
+// execute resolves and runs a parsed program, reporting any problems to diagnostics
+func (l *Lox) execute(statements []Stmt, diagnostics *Diagnostics) error {
+	resolver := NewResolver(&l.interpreter, diagnostics)
+	resolver.resolve_stmts(statements)
+	if !diagnostics.HadError() {
+		analyze(statements, diagnostics)
+	}
+	diagnostics.flushWarnings()
+	if diagnostics.HadError() {
+		return diagnostics
+	}
+
+	var err error
+	if l.vm != nil {
+		script := compile(statements, diagnostics)
+		if diagnostics.HadError() {
+			return diagnostics
+		}
+		_, err = l.vm.interpret(script)
+	} else {
+		err = l.interpreter.interpret(statements)
+	}
+	if err != nil {
+		diagnostics.runtimeError(err)
+		return diagnostics
+	}
+	return nil
+}
+
+// parse scans and parses source, reporting any problems to diagnostics
+func (l *Lox) parse(source string, diagnostics *Diagnostics) []Stmt {
+	scanner := NewScanner(source)
+	tokens := scanner.ScanTokens(diagnostics)
+	parser := NewParser(tokens, diagnostics)
+	return parser.parse()
+}
+
+// Eval evaluates a single expression against the current globals and returns its value.
+func (l *Lox) Eval(source string) (any, error) {
+	diagnostics := NewDiagnostics(l.stderr)
+
+	scanner := NewScanner(source)
+	tokens := scanner.ScanTokens(diagnostics)
+	parser := NewParser(tokens, diagnostics)
+	expr, _ := parser.parseExpression()
+	if diagnostics.HadError() {
+		return nil, diagnostics
+	}
+
+	resolver := NewResolver(&l.interpreter, diagnostics)
+	resolver.expr_resolve(expr)
+	if !diagnostics.HadError() {
+		analyzeExpression(expr, diagnostics)
+	}
+	diagnostics.flushWarnings()
+	if diagnostics.HadError() {
+		return nil, diagnostics
+	}
+
+	var value any
+	var err error
+	if l.vm != nil {
+		script := compileExpression(expr, diagnostics)
+		if diagnostics.HadError() {
+			return nil, diagnostics
+		}
+		value, err = l.vm.interpret(script)
+	} else {
+		value, err = l.interpreter.evaluate(expr)
+		err = l.interpreter.traced(err)
+	}
+	if err != nil {
+		diagnostics.runtimeError(err)
+		return nil, diagnostics
+	}
+	return value, nil
+}
+
+// Stringify formats a value the same way the print statement does.
+func (l *Lox) Stringify(value any) string {
+	return stringify(value)
+}
@@ -1,4 +1,4 @@
-package main
+package lox
 
 type LoxCallable interface {
 	call(*interpreter, []any) (any, error)
@@ -1,4 +1,4 @@
-package main
+package lox
 
 import "errors"
 
@@ -33,10 +33,20 @@ func (l LoxClass) String() string {
 }
 
 func (l LoxClass) call(inter *interpreter, args []any) (any, error) {
+	err := inter.pushFrame(l.name)
+	if err != nil {
+		return nil, err
+	}
+	defer inter.popFrame()
+
 	instance := NewLoxInstance(l)
 	initalizer, err := l.findMethod("init")
 	if err == nil {
-		initalizer.bind(instance).call(inter, args)
+		// The initializer runs in the class's frame rather than one of its own
+		_, err = initalizer.bind(instance).invoke(inter, args)
+		if err != nil {
+			return nil, inter.traced(err)
+		}
 	}
 	return instance, nil
 }
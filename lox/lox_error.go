@@ -0,0 +1,30 @@
+package lox
+
+import "fmt"
+
+// LoxError is the value a catch block receives for a runtime error raised by the interpreter
+type LoxError struct {
+	message string
+	line    int
+}
+
+func NewLoxError(message string, line int) *LoxError {
+	return &LoxError{
+		message: message,
+		line:    line,
+	}
+}
+
+func (l *LoxError) get(name Token) (any, error) {
+	switch name.lexeme {
+	case "message":
+		return l.message, nil
+	case "line":
+		return float64(l.line), nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined property %s.", name.lexeme))
+}
+
+func (l *LoxError) String() string {
+	return l.message
+}
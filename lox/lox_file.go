@@ -0,0 +1,210 @@
+package lox
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+sandbox confines the file natives to one directory tree. Paths given to them
+are relative to root, and can't reach outside it with .. or through symlinks.
+The zero sandbox has no root and refuses everything.
+*/
+type sandbox struct {
+	root string
+}
+
+// fileNatives are the file natives for an interpreter, bound to its sandbox
+func fileNatives(s sandbox) []nativeFunction {
+	return []nativeFunction{
+		{"readFile", 1, func(args []any) (any, error) {
+			path, err := s.pathArg("readFile", args)
+			if err != nil {
+				return nil, err
+			}
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fileError("readFile", args[0], err)
+			}
+			return string(contents), nil
+		}},
+		{"writeFile", 2, func(args []any) (any, error) {
+			return nil, s.write("writeFile", args, os.O_TRUNC)
+		}},
+		{"appendFile", 2, func(args []any) (any, error) {
+			return nil, s.write("appendFile", args, os.O_APPEND)
+		}},
+		{"listDir", 1, func(args []any) (any, error) {
+			path, err := s.pathArg("listDir", args)
+			if err != nil {
+				return nil, err
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, fileError("listDir", args[0], err)
+			}
+			names := make([]string, len(entries))
+			for i, entry := range entries {
+				names[i] = entry.Name()
+			}
+			return stringList(names), nil
+		}},
+		{"fileExists", 1, func(args []any) (any, error) {
+			path, err := s.pathArg("fileExists", args)
+			if err != nil {
+				return nil, err
+			}
+			_, err = os.Stat(path)
+			return err == nil, nil
+		}},
+	}
+}
+
+func (s sandbox) write(function string, args []any, mode int) error {
+	path, err := s.pathArg(function, args)
+	if err != nil {
+		return err
+	}
+	contents, err := stringArg(function, args, 1)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0o644)
+	if err != nil {
+		return fileError(function, args[0], err)
+	}
+	_, err = f.WriteString(contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fileError(function, args[0], err)
+	}
+	return nil
+}
+
+// pathArg checks a native's first argument and turns it into a real path inside the sandbox
+func (s sandbox) pathArg(function string, args []any) (string, error) {
+	if s.root == "" {
+		return "", newNativeError("%s() isn't allowed: file access is disabled.", function)
+	}
+	name, err := stringArg(function, args, 0)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(name) {
+		return "", newNativeError("Path %q must be relative to the sandbox root.", name)
+	}
+
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return "", newNativeError("%s() can't use the sandbox root: %s", function, errorReason(err))
+	}
+	path, err := resolveWithin(root, name)
+	if errors.Is(err, errOutsideSandbox) {
+		return "", newNativeError("Path %q is outside the sandbox.", name)
+	} else if err != nil {
+		return "", fileError(function, name, err)
+	}
+	return path, nil
+}
+
+var errOutsideSandbox = errors.New("outside the sandbox")
+
+// Like the OS, give up on paths that go through too many symlinks, which could be a loop
+const maxSymlinks = 40
+
+/*
+resolveWithin follows name from root one component at a time, replacing each
+symlink with its target, and fails if any step leaves root. The path it
+returns has no symlinks left in it, so opening it can't be redirected out of
+the sandbox, even by a link whose target doesn't exist yet. Only the last
+component may be missing, since none of the natives create directories.
+*/
+func resolveWithin(root, name string) (string, error) {
+	path := root
+	rest := splitPath(name)
+	for links := 0; len(rest) > 0; {
+		component := rest[0]
+		rest = rest[1:]
+		if component == ".." {
+			// path never contains symlinks, so its parent is the real one
+			path = filepath.Dir(path)
+			if !within(root, path) {
+				return "", errOutsideSandbox
+			}
+			continue
+		}
+
+		next := filepath.Join(path, component)
+		info, err := os.Lstat(next)
+		if errors.Is(err, fs.ErrNotExist) && len(rest) == 0 {
+			return next, nil
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			path = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: "open", Path: name, Err: errors.New("too many levels of symbolic links")}
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		// Carry on from wherever the link points, walking its target like the rest of the path
+		targetComponents := splitPath(target)
+		if filepath.IsAbs(target) {
+			// Compare without cleaning, so a .. after a symlink in the target is still walked properly
+			rootComponents := splitPath(root)
+			if len(targetComponents) < len(rootComponents) {
+				return "", errOutsideSandbox
+			}
+			for n, component := range rootComponents {
+				if targetComponents[n] != component {
+					return "", errOutsideSandbox
+				}
+			}
+			path, targetComponents = root, targetComponents[len(rootComponents):]
+		}
+		rest = append(targetComponents, rest...)
+	}
+	return path, nil
+}
+
+// splitPath breaks a relative path into components, without resolving .. the way filepath.Clean would
+func splitPath(name string) []string {
+	var components []string
+	for _, component := range strings.Split(filepath.ToSlash(name), "/") {
+		if component != "" && component != "." {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// within reports whether path is root or somewhere below it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileError reports an OS error without the absolute path, which would give away where the sandbox is
+func fileError(function string, name any, err error) error {
+	return newNativeError("%s() failed for %q: %s", function, name, errorReason(err))
+}
+
+func errorReason(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
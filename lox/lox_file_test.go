@@ -0,0 +1,116 @@
+package lox
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileNatives(t *testing.T) {
+	root := t.TempDir()
+	var stdout bytes.Buffer
+	l := New(WithStdout(&stdout), WithFileAccess(root))
+
+	err := l.Run(`writeFile("notes.txt", "one,");
+appendFile("notes.txt", "two");
+print readFile("notes.txt");
+print fileExists("notes.txt");
+print fileExists("missing.txt");
+print listDir(".");`)
+	if err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if got, expected := stdout.String(), "one,two\ntrue\nfalse\n[\"notes.txt\"]\n"; got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+	contents, err := os.ReadFile(filepath.Join(root, "notes.txt"))
+	if err != nil || string(contents) != "one,two" {
+		t.Errorf("notes.txt contained %q (%v)", contents, err)
+	}
+}
+
+func TestFileNativesStayInSandbox(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "root")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(parent, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"dot dot":  `readFile("../secret.txt")`,
+		"absolute": `readFile("` + filepath.Join(parent, "secret.txt") + `")`,
+		"symlink":  `readFile("escape/secret.txt")`,
+		"write":    `writeFile("escape/new.txt", "x")`,
+	}
+	for name, call := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			l := New(WithStdout(&stdout), WithFileAccess(root))
+			err := l.Run("try { " + call + "; print \"escaped\"; } catch (e) { print e.message; }")
+			if err != nil {
+				t.Fatalf("Run() returned %v", err)
+			}
+			if got := stdout.String(); !strings.Contains(got, "sandbox") {
+				t.Errorf("got %q, expected the sandbox to refuse", got)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(parent, "new.txt")); err == nil {
+		t.Errorf("writeFile created a file outside the sandbox")
+	}
+}
+
+// A symlink whose target doesn't exist yet would be followed when writing creates the file
+func TestFileNativesRefuseDanglingLinksOut(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "root")
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"absolute": filepath.Join(parent, "absolute.txt"),
+		"relative": "../relative.txt",
+		// The link is inside the root, but .. from where it leads isn't
+		"dir/up": "../../up.txt",
+		"inside": "dir/new.txt",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"absolute", "relative", "dir/up"} {
+		var stdout bytes.Buffer
+		l := New(WithStdout(&stdout), WithFileAccess(root))
+		if err := l.Run(`try { writeFile("` + name + `", "x"); print "escaped"; } catch (e) { print e.message; }`); err != nil {
+			t.Fatalf("Run() returned %v", err)
+		}
+		if got := stdout.String(); !strings.Contains(got, "outside the sandbox") {
+			t.Errorf("writing through %s printed %q, expected the sandbox to refuse", name, got)
+		}
+	}
+	for _, escaped := range []string{"absolute.txt", "relative.txt", "up.txt"} {
+		if _, err := os.Lstat(filepath.Join(parent, escaped)); err == nil {
+			t.Errorf("writeFile created %s outside the sandbox", escaped)
+		}
+	}
+
+	// Links that stay inside the sandbox still work, even before their target exists
+	var stdout bytes.Buffer
+	l := New(WithStdout(&stdout), WithFileAccess(root))
+	if err := l.Run(`writeFile("inside", "x"); print readFile("dir/new.txt");`); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if got := stdout.String(); got != "x\n" {
+		t.Errorf("printed %q, expected x", got)
+	}
+}
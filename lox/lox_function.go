@@ -0,0 +1,94 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type LoxFunction struct {
+	declaration   Function
+	closure       *Environment
+	isInitializer bool
+	// Set for methods, so tracebacks can show which class they're on
+	className string
+}
+
+func NewLoxFunction(dec Function, clo *Environment, init bool) LoxFunction {
+	return LoxFunction{
+		declaration:   dec,
+		closure:       clo,
+		isInitializer: init,
+	}
+}
+
+func (l LoxFunction) bind(instance LoxInstance) LoxFunction {
+	environment := newLocalEnvironment(l.closure)
+	environment.define("this", instance)
+	bound := NewLoxFunction(l.declaration, environment, l.isInitializer)
+	bound.className = l.className
+	return bound
+
+}
+
+// Implement LoxCallable
+func (l LoxFunction) call(inter *interpreter, args []any) (any, error) {
+	err := inter.pushFrame(l.frameName())
+	if err != nil {
+		return nil, err
+	}
+	defer inter.popFrame()
+
+	value, err := l.invoke(inter, args)
+	return value, inter.traced(err)
+}
+
+// invoke runs the function body without pushing a call frame
+func (l LoxFunction) invoke(inter *interpreter, args []any) (any, error) {
+	env := newLocalEnvironment(l.closure)
+	env.slots = make([]any, 0, len(args))
+	for i := 0; i < len(l.declaration.params); i++ {
+		env.define(
+			l.declaration.params[i].lexeme,
+			args[i],
+		)
+	}
+	err := inter.executeBlock(l.declaration.body, env)
+	if err != nil {
+		switch e := err.(type) {
+		// We actually want to use this as an exception to break early, not as an error that needs reporting
+		case *ReturnError:
+			if l.isInitializer {
+				return l.closure.getAt(0, 0), nil
+			}
+			return e.value, nil
+		default:
+			return nil, e
+		}
+	}
+	if l.isInitializer {
+		return l.closure.getAt(0, 0), nil
+	}
+	return nil, nil
+}
+
+func (l LoxFunction) arity() int {
+	return len(l.declaration.params)
+}
+
+func (l LoxFunction) frameName() string {
+	name := l.declaration.name.lexeme
+	if l.declaration.name.l_type == FUN {
+		name = "anonymous"
+	}
+	if l.className != "" {
+		return l.className + "." + name
+	}
+	return name
+}
+
+func (l LoxFunction) String() string {
+	// Lambdas use their 'fun' keyword as a name
+	if l.declaration.name.l_type == FUN {
+		return "<fn anonymous>"
+	}
+	return fmt.Sprintf("<fn %s>", l.declaration.name.lexeme)
+}
@@ -0,0 +1,82 @@
+package lox
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+/*
+programInput is where readLine() and readAll() get a program's input, which is
+separate from the lines the REPL reads at its prompt. The exception is when
+both are the same terminal: the line editor owns it while the REPL is
+running, so programs read through the editor instead.
+*/
+type programInput struct {
+	in    *bufio.Reader
+	lines lineReader
+}
+
+// readLine returns the next line without its line ending, and false at the end of the input
+func (p *programInput) readLine() (string, bool, error) {
+	if p.lines != nil {
+		line, err := p.lines.readLine("")
+		if err == io.EOF {
+			return "", false, nil
+		} else if err == readline.ErrInterrupt {
+			return "", false, errors.New("interrupted")
+		}
+		return line, err == nil, err
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", false, nil
+	}
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), true, nil
+}
+
+func (p *programInput) readAll() (string, error) {
+	if p.lines == nil {
+		contents, err := io.ReadAll(p.in)
+		return string(contents), err
+	}
+	var contents strings.Builder
+	for {
+		line, ok, err := p.readLine()
+		if !ok || err != nil {
+			return contents.String(), err
+		}
+		contents.WriteString(line + "\n")
+	}
+}
+
+// inputNatives read a program's input from in
+func inputNatives(in *programInput) []nativeFunction {
+	return []nativeFunction{
+		{"readLine", 0, func(args []any) (any, error) {
+			line, ok, err := in.readLine()
+			if err != nil {
+				return nil, newNativeError("readLine() failed: %s", err)
+			}
+			if !ok {
+				return nil, nil
+			}
+			return line, nil
+		}},
+		{"readAll", 0, func(args []any) (any, error) {
+			contents, err := in.readAll()
+			if err != nil {
+				return nil, newNativeError("readAll() failed: %s", err)
+			}
+			return contents, nil
+		}},
+	}
+}
@@ -0,0 +1,111 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// LoxList is the runtime value of a list literal. It's always used through a pointer so lists are shared by reference.
+type LoxList struct {
+	elements []any
+}
+
+func NewLoxList(elements []any) *LoxList {
+	return &LoxList{
+		elements: elements,
+	}
+}
+
+// get looks up a list method by name, bound to this list
+func (l *LoxList) get(name Token) (any, error) {
+	switch name.lexeme {
+	case "length":
+		return nativeFunction{"length", 0, func(args []any) (any, error) {
+			return float64(len(l.elements)), nil
+		}}, nil
+	case "push":
+		return nativeFunction{"push", 1, func(args []any) (any, error) {
+			l.elements = append(l.elements, args[0])
+			return nil, nil
+		}}, nil
+	case "pop":
+		return nativeFunction{"pop", 0, func(args []any) (any, error) {
+			if len(l.elements) == 0 {
+				return nil, newNativeError("Can't pop from an empty list.")
+			}
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return last, nil
+		}}, nil
+	case "insert":
+		return nativeFunction{"insert", 2, func(args []any) (any, error) {
+			// Inserting at the very end is allowed
+			i, err := listIndex(args[0], len(l.elements)+1)
+			if err != nil {
+				return nil, err
+			}
+			l.elements = append(l.elements, nil)
+			copy(l.elements[i+1:], l.elements[i:])
+			l.elements[i] = args[1]
+			return nil, nil
+		}}, nil
+	case "remove":
+		return nativeFunction{"remove", 1, func(args []any) (any, error) {
+			i, err := listIndex(args[0], len(l.elements))
+			if err != nil {
+				return nil, err
+			}
+			removed := l.elements[i]
+			l.elements = append(l.elements[:i], l.elements[i+1:]...)
+			return removed, nil
+		}}, nil
+	case "slice":
+		return nativeFunction{"slice", 2, func(args []any) (any, error) {
+			start, err := listIndex(args[0], len(l.elements)+1)
+			if err != nil {
+				return nil, err
+			}
+			end, err := listIndex(args[1], len(l.elements)+1)
+			if err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, newNativeError("Slice start can't be after its end.")
+			}
+			// Copy so the slice doesn't share storage with this list
+			elements := make([]any, end-start)
+			copy(elements, l.elements[start:end])
+			return NewLoxList(elements), nil
+		}}, nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined property %s.", name.lexeme))
+}
+
+func (l *LoxList) getIndex(bracket Token, index any) (any, error) {
+	i, err := listIndex(index, len(l.elements))
+	if err != nil {
+		return nil, NewRuntimeError(bracket, err.Error())
+	}
+	return l.elements[i], nil
+}
+
+func (l *LoxList) setIndex(bracket Token, index any, value any) error {
+	i, err := listIndex(index, len(l.elements))
+	if err != nil {
+		return NewRuntimeError(bracket, err.Error())
+	}
+	l.elements[i] = value
+	return nil
+}
+
+// listIndex checks that index is a whole number in [0, length)
+func listIndex(index any, length int) (int, error) {
+	n, ok := index.(float64)
+	if !ok || n != math.Trunc(n) {
+		return 0, newNativeError("List index must be an integer.")
+	}
+	if n < 0 || n >= float64(length) {
+		return 0, newNativeError("List index %s out of range.", fmt.Sprint(n))
+	}
+	return int(n), nil
+}
@@ -0,0 +1,118 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// LoxMap is the runtime value of a map literal. Keys keep their insertion order so maps always print the same way.
+type LoxMap struct {
+	keys   []any
+	values map[any]any
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		values: make(map[any]any),
+	}
+}
+
+// get looks up a map method by name, bound to this map
+func (m *LoxMap) get(name Token) (any, error) {
+	switch name.lexeme {
+	case "length":
+		return nativeFunction{"length", 0, func(args []any) (any, error) {
+			return float64(len(m.keys)), nil
+		}}, nil
+	case "keys":
+		return nativeFunction{"keys", 0, func(args []any) (any, error) {
+			keys := make([]any, len(m.keys))
+			copy(keys, m.keys)
+			return NewLoxList(keys), nil
+		}}, nil
+	case "values":
+		return nativeFunction{"values", 0, func(args []any) (any, error) {
+			values := make([]any, len(m.keys))
+			for i, key := range m.keys {
+				values[i] = m.values[key]
+			}
+			return NewLoxList(values), nil
+		}}, nil
+	case "has":
+		return nativeFunction{"has", 1, func(args []any) (any, error) {
+			if err := checkMapKey(args[0]); err != nil {
+				return nil, err
+			}
+			_, ok := m.values[args[0]]
+			return ok, nil
+		}}, nil
+	case "delete":
+		return nativeFunction{"delete", 1, func(args []any) (any, error) {
+			if err := checkMapKey(args[0]); err != nil {
+				return nil, err
+			}
+			return m.delete(args[0]), nil
+		}}, nil
+	}
+	return nil, NewRuntimeError(name, fmt.Sprintf("Undefined property %s.", name.lexeme))
+}
+
+func (m *LoxMap) getIndex(bracket Token, key any) (any, error) {
+	if err := checkMapKey(key); err != nil {
+		return nil, NewRuntimeError(bracket, err.Error())
+	}
+	value, ok := m.values[key]
+	if !ok {
+		return nil, NewRuntimeError(bracket, fmt.Sprintf("Undefined key %s.", stringifyElement(key, nil)))
+	}
+	return value, nil
+}
+
+func (m *LoxMap) setIndex(bracket Token, key any, value any) error {
+	if err := checkMapKey(key); err != nil {
+		return NewRuntimeError(bracket, err.Error())
+	}
+	m.set(key, value)
+	return nil
+}
+
+// set stores a value, adding key to the end of the order if it's new
+func (m *LoxMap) set(key any, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// delete removes key, returning whether it was there
+func (m *LoxMap) delete(key any) bool {
+	if _, ok := m.values[key]; !ok {
+		return false
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+/*
+checkMapKey only allows keys that compare by value, so that
+lookups follow the same rules as isEqual. NaN isn't even equal
+to itself, so a NaN key could never be found again.
+*/
+func checkMapKey(key any) error {
+	switch k := key.(type) {
+	case float64:
+		if math.IsNaN(k) {
+			return newNativeError("Map keys can't be NaN.")
+		}
+		return nil
+	case nil, bool, string:
+		return nil
+	}
+	return newNativeError("Map keys must be strings, numbers, booleans or nil.")
+}
@@ -0,0 +1,103 @@
+package lox
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mathNatives are the global math functions. PI is defined alongside them as a plain number.
+var mathNatives = []nativeFunction{
+	mathFunction("floor", math.Floor),
+	mathFunction("ceil", math.Ceil),
+	mathFunction("round", math.Round),
+	mathFunction("sqrt", math.Sqrt),
+	mathFunction("abs", math.Abs),
+	mathFunction("sin", math.Sin),
+	mathFunction("cos", math.Cos),
+	mathFunction("log", math.Log),
+	mathFunction("exp", math.Exp),
+	{"pow", 2, func(args []any) (any, error) {
+		x, err := numberArg("pow", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		y, err := numberArg("pow", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return math.Pow(x, y), nil
+	}},
+	extremum("min", math.Min),
+	extremum("max", math.Max),
+	{"num", 1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case float64:
+			return v, nil
+		case string:
+			// Anything that doesn't look like a number gives nil, so scripts can check input
+			s := strings.TrimSpace(v)
+			if !numberPattern.MatchString(s) {
+				return nil, nil
+			}
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, nil
+			}
+			return n, nil
+		}
+		return nil, newNativeError("num() expects a string or a number.")
+	}},
+	{"str", 1, func(args []any) (any, error) {
+		return stringify(args[0]), nil
+	}},
+}
+
+// The numbers num() accepts: decimals with an optional sign and exponent, but not "inf" or hex
+var numberPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+func defineMath(env *Environment) {
+	defineNatives(env, mathNatives)
+	env.define("PI", math.Pi)
+}
+
+// mathFunction wraps a one-argument Go math function
+func mathFunction(name string, fn func(float64) float64) nativeFunction {
+	return nativeFunction{name, 1, func(args []any) (any, error) {
+		x, err := numberArg(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(x), nil
+	}}
+}
+
+// extremum builds min or max, which take one or more numbers
+func extremum(name string, pick func(float64, float64) float64) nativeFunction {
+	return nativeFunction{name, variadic, func(args []any) (any, error) {
+		if len(args) == 0 {
+			return nil, newNativeError("%s() expects at least one number.", name)
+		}
+		result, err := numberArg(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for n := range args[1:] {
+			x, err := numberArg(name, args, n+1)
+			if err != nil {
+				return nil, err
+			}
+			result = pick(result, x)
+		}
+		return result, nil
+	}}
+}
+
+func numberArg(function string, args []any, n int) (float64, error) {
+	x, ok := args[n].(float64)
+	if !ok {
+		return 0, newNativeError("Argument %d to %s() must be a number.", n+1, function)
+	}
+	return x, nil
+}
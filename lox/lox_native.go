@@ -0,0 +1,49 @@
+package lox
+
+import "fmt"
+
+// Arity of natives that take any number of arguments, like format
+const variadic = -1
+
+// nativeFunction is a LoxCallable implemented in Go, like the built-in clock
+type nativeFunction struct {
+	name   string
+	params int
+	fn     func(args []any) (any, error)
+}
+
+// defineNatives adds natives to env as global functions
+func defineNatives(env *Environment, natives []nativeFunction) {
+	for _, native := range natives {
+		env.define(native.name, native)
+	}
+}
+
+func (n nativeFunction) arity() int {
+	return n.params
+}
+
+func (n nativeFunction) call(inter *interpreter, args []any) (any, error) {
+	return n.fn(args)
+}
+
+func (n nativeFunction) String() string {
+	return fmt.Sprintf("<fn %s>", n.name)
+}
+
+/*
+nativeError is returned by natives that are given bad arguments.
+Natives don't know where they were called from, so the interpreter
+turns it into a RuntimeError at the call site.
+*/
+type nativeError struct {
+	message string
+}
+
+func newNativeError(format string, a ...any) *nativeError {
+	return &nativeError{fmt.Sprintf(format, a...)}
+}
+
+func (e nativeError) Error() string {
+	return e.message
+}
@@ -0,0 +1,168 @@
+package lox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	expectOutputPattern       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectErrorPattern        = regexp.MustCompile(`// (Error( at ('.*'|end))?: .*)`)
+	expectLineErrorPattern    = regexp.MustCompile(`// \[line (\d+)\] (Error( at ('.*'|end))?: .*)`)
+	expectRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)`)
+	reportedErrorPattern      = regexp.MustCompile(`^\[line \d+\] Error`)
+	reportedWarningPattern    = regexp.MustCompile(`^\[line \d+\] Warning`)
+)
+
+// expectations is what a script in lox_programs says it should do,
+// using the comment annotations from the official Crafting Interpreters tests.
+type expectations struct {
+	output       []string
+	errors       []string
+	runtimeError string
+	runtimeLine  int
+	exitCode     int
+}
+
+func parseExpectations(path string) (expectations, error) {
+	var e expectations
+	f, err := os.Open(path)
+	if err != nil {
+		return e, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if m := expectOutputPattern.FindStringSubmatch(text); m != nil {
+			e.output = append(e.output, m[1])
+		} else if m := expectLineErrorPattern.FindStringSubmatch(text); m != nil {
+			e.errors = append(e.errors, fmt.Sprintf("[line %s] %s", m[1], m[2]))
+			e.exitCode = 65
+		} else if m := expectErrorPattern.FindStringSubmatch(text); m != nil {
+			e.errors = append(e.errors, fmt.Sprintf("[line %d] %s", line, m[1]))
+			e.exitCode = 65
+		} else if m := expectRuntimeErrorPattern.FindStringSubmatch(text); m != nil {
+			e.runtimeError = m[1]
+			e.runtimeLine = line
+			e.exitCode = 70
+		}
+	}
+	return e, s.Err()
+}
+
+// exitCode maps the result of Run onto the exit code the CLI would use.
+func exitCode(err error) int {
+	var runtimeErr *RuntimeError
+	if errors.Is(err, ErrCompile) {
+		return 65
+	} else if errors.As(err, &runtimeErr) {
+		return 70
+	} else if err != nil {
+		return 1
+	}
+	return 0
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// backends are the ways a Lox can run a program, which all have to pass the same scripts
+var backends = []struct {
+	name    string
+	options []Option
+}{
+	{"interpreter", nil},
+	{"vm", []Option{WithBytecode()}},
+}
+
+func TestLoxTestScripts(t *testing.T) {
+	files, err := filepath.Glob("../lox_programs/*.lox")
+	if err != nil {
+		panic(err)
+	}
+	for _, backend := range backends {
+		for _, file := range files {
+			testScript(t, backend.name, file, backend.options)
+		}
+	}
+}
+
+func testScript(t *testing.T, backend, file string, options []Option) {
+	t.Helper()
+	t.Run(backend+"/"+filepath.Base(file), func(t *testing.T) {
+		expected, err := parseExpectations(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stdout, stderr bytes.Buffer
+		a := New(append(options, WithStdout(&stdout), WithStderr(&stderr))...)
+		code := exitCode(a.RunFile(file))
+
+		if output := splitLines(stdout.String()); !reflect.DeepEqual(output, expected.output) {
+			t.Errorf("output was\n%q\nexpected\n%q", output, expected.output)
+		}
+
+		diagnostics := splitLines(stderr.String())
+		if expected.runtimeError != "" {
+			line := "[line " + strconv.Itoa(expected.runtimeLine) + "]"
+			if len(diagnostics) < 2 || diagnostics[0] != expected.runtimeError || !strings.HasPrefix(diagnostics[1], line) {
+				t.Errorf("runtime error was\n%q\nexpected %q at %s", diagnostics, expected.runtimeError, line)
+			}
+		} else {
+			var reported []string
+			for _, d := range diagnostics {
+				if reportedErrorPattern.MatchString(d) {
+					reported = append(reported, d)
+				}
+			}
+			if !reflect.DeepEqual(reported, expected.errors) {
+				t.Errorf("errors were\n%q\nexpected\n%q", reported, expected.errors)
+			}
+		}
+
+		if code != expected.exitCode {
+			t.Errorf("exit code was %d, expected %d", code, expected.exitCode)
+		}
+	})
+}
+
+// The scripts only check the first lines of a runtime error, so make sure the backends' whole reports match too
+func TestBackendsAgree(t *testing.T) {
+	files, err := filepath.Glob("../lox_programs/*.lox")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			var outputs []string
+			for _, backend := range backends {
+				var stdout, stderr bytes.Buffer
+				New(append(backend.options, WithStdout(&stdout), WithStderr(&stderr))...).RunFile(file)
+				outputs = append(outputs, stdout.String()+stderr.String())
+			}
+			for n := 1; n < len(outputs); n++ {
+				if outputs[n] != outputs[0] {
+					t.Errorf("%s printed\n%s\n%s printed\n%s", backends[n].name, outputs[n], backends[0].name, outputs[0])
+				}
+			}
+		})
+	}
+}
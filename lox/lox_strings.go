@@ -0,0 +1,188 @@
+package lox
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringNatives are the global functions for working with strings. Indexes count characters, not bytes.
+var stringNatives = []nativeFunction{
+	{"len", 1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case *LoxList:
+			return float64(len(v.elements)), nil
+		case *LoxMap:
+			return float64(len(v.keys)), nil
+		}
+		return nil, newNativeError("len() expects a string, list or map.")
+	}},
+	{"substr", 3, func(args []any) (any, error) {
+		s, err := stringArg("substr", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		chars := []rune(s)
+		// Both ends may be one past the last character
+		start, err := charIndex("substr", args[1], len(chars)+1)
+		if err != nil {
+			return nil, err
+		}
+		end, err := charIndex("substr", args[2], len(chars)+1)
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, newNativeError("substr() start can't be after its end.")
+		}
+		return string(chars[start:end]), nil
+	}},
+	{"indexOf", 2, func(args []any) (any, error) {
+		s, sub, err := twoStringArgs("indexOf", args)
+		if err != nil {
+			return nil, err
+		}
+		i := strings.Index(s, sub)
+		if i < 0 {
+			return -1.0, nil
+		}
+		return float64(utf8.RuneCountInString(s[:i])), nil
+	}},
+	{"split", 2, func(args []any) (any, error) {
+		s, sep, err := twoStringArgs("split", args)
+		if err != nil {
+			return nil, err
+		}
+		return stringList(strings.Split(s, sep)), nil
+	}},
+	{"join", 2, func(args []any) (any, error) {
+		list, ok := args[0].(*LoxList)
+		if !ok {
+			return nil, newNativeError("Argument 1 to join() must be a list.")
+		}
+		sep, err := stringArg("join", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, len(list.elements))
+		for i, element := range list.elements {
+			parts[i] = stringify(element)
+		}
+		return strings.Join(parts, sep), nil
+	}},
+	{"upper", 1, func(args []any) (any, error) {
+		s, err := stringArg("upper", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return strings.ToUpper(s), nil
+	}},
+	{"lower", 1, func(args []any) (any, error) {
+		s, err := stringArg("lower", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return strings.ToLower(s), nil
+	}},
+	{"trim", 1, func(args []any) (any, error) {
+		s, err := stringArg("trim", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return strings.TrimSpace(s), nil
+	}},
+	{"replace", 3, func(args []any) (any, error) {
+		s, old, err := twoStringArgs("replace", args)
+		if err != nil {
+			return nil, err
+		}
+		replacement, err := stringArg("replace", args, 2)
+		if err != nil {
+			return nil, err
+		}
+		return strings.ReplaceAll(s, old, replacement), nil
+	}},
+	{"startsWith", 2, func(args []any) (any, error) {
+		s, prefix, err := twoStringArgs("startsWith", args)
+		if err != nil {
+			return nil, err
+		}
+		return strings.HasPrefix(s, prefix), nil
+	}},
+	{"endsWith", 2, func(args []any) (any, error) {
+		s, suffix, err := twoStringArgs("endsWith", args)
+		if err != nil {
+			return nil, err
+		}
+		return strings.HasSuffix(s, suffix), nil
+	}},
+	{"chars", 1, func(args []any) (any, error) {
+		s, err := stringArg("chars", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return stringList(strings.Split(s, "")), nil
+	}},
+	{"format", variadic, func(args []any) (any, error) {
+		if len(args) == 0 {
+			return nil, newNativeError("format() expects a template string.")
+		}
+		template, err := stringArg("format", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		// Each {} is replaced by the next argument, printed the way print would
+		values := args[1:]
+		parts := strings.Split(template, "{}")
+		if len(parts)-1 != len(values) {
+			return nil, newNativeError("format() template has %d placeholders but got %d values.", len(parts)-1, len(values))
+		}
+		var b strings.Builder
+		for i, part := range parts {
+			b.WriteString(part)
+			if i < len(values) {
+				b.WriteString(stringify(values[i]))
+			}
+		}
+		return b.String(), nil
+	}},
+}
+
+func stringArg(function string, args []any, n int) (string, error) {
+	s, ok := args[n].(string)
+	if !ok {
+		return "", newNativeError("Argument %d to %s() must be a string.", n+1, function)
+	}
+	return s, nil
+}
+
+func twoStringArgs(function string, args []any) (string, string, error) {
+	a, err := stringArg(function, args, 0)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := stringArg(function, args, 1)
+	return a, b, err
+}
+
+// charIndex checks that index is a whole number in [0, length)
+func charIndex(function string, index any, length int) (int, error) {
+	n, ok := index.(float64)
+	if !ok || n != math.Trunc(n) {
+		return 0, newNativeError("%s() index must be an integer.", function)
+	}
+	if n < 0 || n >= float64(length) {
+		return 0, newNativeError("%s() index %s out of range.", function, stringify(n))
+	}
+	return int(n), nil
+}
+
+func stringList(parts []string) *LoxList {
+	elements := make([]any, len(parts))
+	for i, part := range parts {
+		elements[i] = part
+	}
+	return NewLoxList(elements)
+}
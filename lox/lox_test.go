@@ -0,0 +1,81 @@
+package lox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintWritesToStdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	l := New(WithStdout(&stdout), WithStderr(&stderr))
+
+	if err := l.Run("print 1 + 2;\nprint \"done\";"); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if got, expected := stdout.String(), "3\ndone\n"; got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("stderr was %q, expected nothing", stderr.String())
+	}
+}
+
+func TestErrorsWriteToStderr(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	l := New(WithStdout(&stdout), WithStderr(&stderr))
+
+	l.Run("print 1;\nprint nil + 1;")
+	if got, expected := stdout.String(), "1\n"; got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+	if got, expected := stderr.String(), "operands must be two numbers or two strings.\n[line 2] in script\n   2 | print nil + 1;\n     |           ^\n"; got != expected {
+		t.Errorf("stderr was %q, expected %q", got, expected)
+	}
+}
+
+func TestEvalUsesGlobals(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New(WithStdout(&stdout))
+
+	if err := l.Run("var a = 20;"); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	got, err := l.Eval("(a + 1) * 2")
+	if err != nil {
+		t.Fatalf("Eval() returned %v", err)
+	}
+	if got != 42.0 {
+		t.Errorf("Eval() was %v, expected 42", got)
+	}
+}
+
+func TestReadInputFromStdin(t *testing.T) {
+	var stdout bytes.Buffer
+	stdin := strings.NewReader("first\r\nsecond\nthe rest\nof it")
+	l := New(WithStdout(&stdout), WithStdin(stdin))
+
+	err := l.Run(`print readLine();
+print readLine();
+print readAll();
+print readLine();
+print readAll() == "";`)
+	if err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if got, expected := stdout.String(), "first\nsecond\nthe rest\nof it\nnil\ntrue\n"; got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+}
+
+func TestReadLineKeepsLastLineWithoutNewline(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New(WithStdout(&stdout), WithStdin(strings.NewReader("a\nb")))
+
+	if err := l.Run("var line = readLine(); while (line != nil) { print line; line = readLine(); }"); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if got, expected := stdout.String(), "a\nb\n"; got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,69 @@
+// Code generated by "stringer -type=OpCode"; DO NOT EDIT.
+
+package lox
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[OP_CONSTANT-0]
+	_ = x[OP_NIL-1]
+	_ = x[OP_TRUE-2]
+	_ = x[OP_FALSE-3]
+	_ = x[OP_POP-4]
+	_ = x[OP_GET_LOCAL-5]
+	_ = x[OP_SET_LOCAL-6]
+	_ = x[OP_GET_GLOBAL-7]
+	_ = x[OP_DEFINE_GLOBAL-8]
+	_ = x[OP_SET_GLOBAL-9]
+	_ = x[OP_GET_UPVALUE-10]
+	_ = x[OP_SET_UPVALUE-11]
+	_ = x[OP_GET_PROPERTY-12]
+	_ = x[OP_SET_PROPERTY-13]
+	_ = x[OP_GET_SUPER-14]
+	_ = x[OP_GET_INDEX-15]
+	_ = x[OP_SET_INDEX-16]
+	_ = x[OP_EQUAL-17]
+	_ = x[OP_GREATER-18]
+	_ = x[OP_GREATER_EQUAL-19]
+	_ = x[OP_LESS-20]
+	_ = x[OP_LESS_EQUAL-21]
+	_ = x[OP_ADD-22]
+	_ = x[OP_SUBTRACT-23]
+	_ = x[OP_MULTIPLY-24]
+	_ = x[OP_DIVIDE-25]
+	_ = x[OP_NOT-26]
+	_ = x[OP_NEGATE-27]
+	_ = x[OP_PRINT-28]
+	_ = x[OP_JUMP-29]
+	_ = x[OP_JUMP_IF_FALSE-30]
+	_ = x[OP_LOOP-31]
+	_ = x[OP_CALL-32]
+	_ = x[OP_CLOSURE-33]
+	_ = x[OP_CLOSE_UPVALUE-34]
+	_ = x[OP_RETURN-35]
+	_ = x[OP_CLASS-36]
+	_ = x[OP_INHERIT-37]
+	_ = x[OP_METHOD-38]
+	_ = x[OP_LIST-39]
+	_ = x[OP_MAP-40]
+	_ = x[OP_THROW-41]
+	_ = x[OP_PUSH_HANDLER-42]
+	_ = x[OP_POP_HANDLER-43]
+	_ = x[OP_RETHROW-44]
+	_ = x[OP_ERROR_VALUE-45]
+}
+
+const _OpCode_name = "OP_CONSTANTOP_NILOP_TRUEOP_FALSEOP_POPOP_GET_LOCALOP_SET_LOCALOP_GET_GLOBALOP_DEFINE_GLOBALOP_SET_GLOBALOP_GET_UPVALUEOP_SET_UPVALUEOP_GET_PROPERTYOP_SET_PROPERTYOP_GET_SUPEROP_GET_INDEXOP_SET_INDEXOP_EQUALOP_GREATEROP_GREATER_EQUALOP_LESSOP_LESS_EQUALOP_ADDOP_SUBTRACTOP_MULTIPLYOP_DIVIDEOP_NOTOP_NEGATEOP_PRINTOP_JUMPOP_JUMP_IF_FALSEOP_LOOPOP_CALLOP_CLOSUREOP_CLOSE_UPVALUEOP_RETURNOP_CLASSOP_INHERITOP_METHODOP_LISTOP_MAPOP_THROWOP_PUSH_HANDLEROP_POP_HANDLEROP_RETHROWOP_ERROR_VALUE"
+
+var _OpCode_index = [...]uint16{0, 11, 17, 24, 32, 38, 50, 62, 75, 91, 104, 118, 132, 147, 162, 174, 186, 198, 206, 216, 232, 239, 252, 258, 269, 280, 289, 295, 304, 312, 319, 335, 342, 349, 359, 375, 384, 392, 402, 411, 418, 424, 432, 447, 461, 471, 485}
+
+func (i OpCode) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_OpCode_index)-1 {
+		return "OpCode(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _OpCode_name[_OpCode_index[idx]:_OpCode_index[idx+1]]
+}
@@ -1,36 +1,49 @@
-package main
+package lox
 
 import (
 	"fmt"
 )
 
 type Parser struct {
-	tokens  []Token
-	current int
+	tokens      []Token
+	current     int
+	diagnostics *Diagnostics
+	errors      []ParseError
 }
 
+// ParseError is a syntax error at a specific token.
 type ParseError struct {
-	err error
+	token   Token
+	message string
 }
 
 func (e ParseError) Error() string {
-	return fmt.Sprintf("ParseError: %v", e.err)
+	if e.token.l_type == EOF {
+		return fmt.Sprintf("[line %d] Error at end: %s", e.token.line, e.message)
+	}
+	return fmt.Sprintf("[line %d] Error at '%s': %s", e.token.line, e.token.lexeme, e.message)
 }
 
-func NewParser(tokens []Token, l *Lox) *Parser {
+func NewParser(tokens []Token, d *Diagnostics) *Parser {
 	return &Parser{
-		current: 0,
-		tokens:  tokens,
+		current:     0,
+		tokens:      tokens,
+		diagnostics: d,
 	}
 }
 
+/*
+parse parses declarations until EOF. A declaration with a syntax error is
+dropped and parsing carries on after it, so every error in the source is
+reported. They are all available from p.errors afterwards.
+*/
 func (p *Parser) parse() []Stmt {
 	var statements []Stmt
 	for !p.isAtEnd() {
 		dec, err := p.declaration()
 
 		if err != nil {
-			break
+			continue
 		}
 
 		statements = append(statements, dec)
@@ -38,18 +51,33 @@ func (p *Parser) parse() []Stmt {
 	return statements
 }
 
-func (p *Parser) declaration() (Stmt, error) {
-	if p.match(CLASS) {
-		return p.classDeclaration()
-	}
-	if p.match(FUN) {
-		return p.function("function")
+// parseExpression parses source that must consist of exactly one expression.
+func (p *Parser) parseExpression() (Expr, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
 	}
-	if p.match(VAR) {
-		return p.varDeclaration()
+	if !p.isAtEnd() {
+		return nil, p.error(p.peek(), "Expect end of expression.")
 	}
+	return expr, nil
+}
 
-	state, err := p.statement()
+// declaration parses a single declaration, synchronizing to the start of the next one on error.
+func (p *Parser) declaration() (Stmt, error) {
+	var state Stmt
+	var err error
+	if p.match(CLASS) {
+		state, err = p.classDeclaration()
+	} else if p.check(FUN) && p.checkNext(IDENTIFIER) {
+		// Without a name, 'fun' starts a lambda in an expression statement instead
+		p.advance()
+		state, err = p.function("function")
+	} else if p.match(VAR) {
+		state, err = p.varDeclaration()
+	} else {
+		state, err = p.statement()
+	}
 
 	if err != nil {
 		p.synchronize()
@@ -103,6 +131,18 @@ func (p *Parser) statement() (Stmt, error) {
 	if p.match(RETURN) {
 		return p.returnStatement()
 	}
+	if p.match(BREAK) {
+		return p.breakStatement()
+	}
+	if p.match(CONTINUE) {
+		return p.continueStatement()
+	}
+	if p.match(THROW) {
+		return p.throwStatement()
+	}
+	if p.match(TRY) {
+		return p.tryStatement()
+	}
 	if p.match(FOR) {
 		return p.forStatement()
 	}
@@ -121,6 +161,7 @@ func (p *Parser) statement() (Stmt, error) {
 }
 
 func (p *Parser) forStatement() (Stmt, error) {
+	keyword := p.previous()
 	// Desugar a for-loop to a while loop
 	_, err := p.consume(LEFT_PAREN, "Expect '(' after 'for'.")
 	if err != nil {
@@ -177,14 +218,11 @@ func (p *Parser) forStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	if increment != nil {
-		body = &Block{[]Stmt{body, &Expression{increment}}}
-	}
-
 	if condition == nil {
-		condition = &Literal{true}
+		condition = &Literal{keyword, true}
 	}
-	body = &While{condition, body}
+	// The increment stays on the While so that 'continue' still runs it
+	body = &While{condition, body, increment}
 
 	if initializer != nil {
 		body = &Block{[]Stmt{initializer, body}}
@@ -194,6 +232,7 @@ func (p *Parser) forStatement() (Stmt, error) {
 }
 
 func (p *Parser) ifStatement() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(LEFT_PAREN, "Expect '(' after 'if'.")
 	if err != nil {
 		return nil, err
@@ -222,19 +261,23 @@ func (p *Parser) ifStatement() (Stmt, error) {
 		}
 	}
 
-	return &If{condition, thenBranch, elseBranch}, nil
+	return &If{keyword, condition, thenBranch, elseBranch}, nil
 
 }
 
 func (p *Parser) printStatement() (Stmt, error) {
-	value, _ := p.expression()
-	_, err := p.consume(SEMICOLON, "Expect ';' after value.")
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(SEMICOLON, "Expect ';' after value.")
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &Print{value}, nil
+	return &Print{keyword, value}, nil
 }
 
 func (p *Parser) returnStatement() (Stmt, error) {
@@ -248,10 +291,100 @@ func (p *Parser) returnStatement() (Stmt, error) {
 			return nil, err
 		}
 	}
-	p.consume(SEMICOLON, "Expect ';' after return value.")
+	_, err = p.consume(SEMICOLON, "Expect ';' after return value.")
+	if err != nil {
+		return nil, err
+	}
 	return &Return{keyword, value}, nil
 }
 
+func (p *Parser) breakStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(SEMICOLON, "Expect ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+	return &Break{keyword}, nil
+}
+
+func (p *Parser) continueStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(SEMICOLON, "Expect ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+	return &Continue{keyword}, nil
+}
+
+func (p *Parser) throwStatement() (Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(SEMICOLON, "Expect ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+	return &Throw{keyword, value}, nil
+}
+
+func (p *Parser) tryStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(LEFT_BRACE, "Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+	tryBranch, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	// The catch variable's name is left empty when there's no catch
+	var catchName Token
+	var catchBranch []Stmt
+	if p.match(CATCH) {
+		_, err = p.consume(LEFT_PAREN, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+		catchName, err = p.consume(IDENTIFIER, "Expect exception variable name.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(RIGHT_PAREN, "Expect ')' after exception variable.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(LEFT_BRACE, "Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+		catchBranch, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finallyBranch []Stmt
+	hasFinally := p.match(FINALLY)
+	if hasFinally {
+		_, err = p.consume(LEFT_BRACE, "Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+		finallyBranch, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catchName.lexeme == "" && !hasFinally {
+		return nil, p.error(p.peek(), "Expect 'catch' or 'finally' after try block.")
+	}
+	return &Try{keyword, tryBranch, catchName, catchBranch, finallyBranch}, nil
+}
+
 func (p *Parser) varDeclaration() (Stmt, error) {
 	var initial Expr
 	name, err := p.consume(IDENTIFIER, "Expect variable name.")
@@ -299,12 +432,15 @@ func (p *Parser) whileStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	return &While{condition, body}, nil
+	return &While{condition, body, nil}, nil
 }
 
 func (p *Parser) expressionStatement() (Stmt, error) {
-	expr, _ := p.expression()
-	_, err := p.consume(SEMICOLON, "Expect ';' after expression.")
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(SEMICOLON, "Expect ';' after expression.")
 
 	if err != nil {
 		return nil, err
@@ -321,17 +457,43 @@ func (p *Parser) function(kind string) (*Function, error) {
 	if err != nil {
 		return nil, err
 	}
-	p.consume(LEFT_PAREN, fmt.Sprintf("Expect '(' after %s name.", kind))
+	_, err = p.consume(LEFT_PAREN, fmt.Sprintf("Expect '(' after %s name.", kind))
+	if err != nil {
+		return nil, err
+	}
+	params, body, err := p.functionBody(kind)
+	if err != nil {
+		return nil, err
+	}
+	return &Function{name, params, body}, nil
+}
+
+// lambda parses an anonymous function expression, after its 'fun' keyword
+func (p *Parser) lambda() (Expr, error) {
+	keyword := p.previous()
+	_, err := p.consume(LEFT_PAREN, "Expect '(' after 'fun'.")
+	if err != nil {
+		return nil, err
+	}
+	params, body, err := p.functionBody("function")
+	if err != nil {
+		return nil, err
+	}
+	return &Lambda{keyword, params, body}, nil
+}
+
+// functionBody parses the parameters and body of a function, after the opening '('
+func (p *Parser) functionBody(kind string) ([]Token, []Stmt, error) {
 	var params []Token
 	if !p.check(RIGHT_PAREN) {
 		// Do-while loop
 		for {
 			if len(params) >= 255 {
-				return nil, p.error(p.peek(), "Can't have more than 255 parameters.")
+				return nil, nil, p.error(p.peek(), "Can't have more than 255 parameters.")
 			}
 			to_append, err := p.consume(IDENTIFIER, "Expect parameter name.")
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			params = append(params, to_append)
 
@@ -341,13 +503,19 @@ func (p *Parser) function(kind string) (*Function, error) {
 			}
 		}
 	}
-	p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
-	p.consume(LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body.", kind))
+	_, err := p.consume(RIGHT_PAREN, "Expect ')' after parameters.")
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = p.consume(LEFT_BRACE, fmt.Sprintf("Expect '{' before %s body.", kind))
+	if err != nil {
+		return nil, nil, err
+	}
 	body, err := p.block()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &Function{name, params, body}, nil
+	return params, body, nil
 }
 
 func (p *Parser) block() ([]Stmt, error) {
@@ -356,12 +524,16 @@ func (p *Parser) block() ([]Stmt, error) {
 	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
 		dec, err := p.declaration()
 		if err != nil {
-			return nil, err
+			// Already reported and synchronized, keep looking for more errors
+			continue
 		}
 		statements = append(statements, dec)
 	}
 
-	p.consume(RIGHT_BRACE, "Expect '}' after block.")
+	_, err := p.consume(RIGHT_BRACE, "Expect '}' after block.")
+	if err != nil {
+		return nil, err
+	}
 	return statements, nil
 }
 
@@ -381,6 +553,8 @@ func (p *Parser) assignment() (Expr, error) {
 			return &Assign{name, value}, nil
 		} else if g, ok := expr.(*Get); ok {
 			return &Set{g.object, g.name, value}, nil
+		} else if idx, ok := expr.(*Index); ok {
+			return &SetIndex{idx.object, idx.bracket, idx.index, value}, nil
 		}
 		return nil, p.error(equals, "Invalid assignment target.")
 	}
@@ -520,17 +694,18 @@ func (p *Parser) unary() (Expr, error) {
 finishCall checks for any arguments passed to a function and calls itself for each argument sent
 If there are no arguments we don't try to parse
 */
-func (p *Parser) finishCall(callee Expr) Expr {
+func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	var arguments []Expr
 	if !p.check(RIGHT_PAREN) {
 		// Mimic do-while loop
 		for {
 			if len(arguments) >= 255 {
+				// Report, but the parser isn't confused so don't unwind
 				p.error(p.peek(), "Can't have more than 255 arguments.")
 			}
 			exp, err := p.expression()
 			if err != nil {
-				fmt.Println("Error in finish Call")
+				return nil, err
 			}
 			arguments = append(arguments, exp)
 
@@ -542,10 +717,10 @@ func (p *Parser) finishCall(callee Expr) Expr {
 
 	paren, err := p.consume(RIGHT_PAREN, "Expect ')' after arguments.")
 	if err != nil {
-		fmt.Println("Error in finish Call consume")
+		return nil, err
 	}
 
-	return &Call{callee, paren, arguments}
+	return &Call{callee, paren, arguments}, nil
 
 }
 
@@ -557,13 +732,26 @@ func (p *Parser) call() (Expr, error) {
 
 	for {
 		if p.match(LEFT_PAREN) {
-			expr = p.finishCall(expr)
+			expr, err = p.finishCall(expr)
+			if err != nil {
+				return nil, err
+			}
 		} else if p.match(DOT) {
 			name, err := p.consume(IDENTIFIER, "Expect property name after '.'.")
 			if err != nil {
 				return nil, err
 			}
 			expr = &Get{expr, name}
+		} else if p.match(LEFT_BRACKET) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			bracket, err := p.consume(RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &Index{expr, bracket, index}
 		} else {
 			break
 		}
@@ -574,17 +762,17 @@ func (p *Parser) call() (Expr, error) {
 
 func (p *Parser) primary() (Expr, error) {
 	if p.match(FALSE) {
-		return &Literal{false}, nil
+		return &Literal{p.previous(), false}, nil
 	}
 	if p.match(TRUE) {
-		return &Literal{true}, nil
+		return &Literal{p.previous(), true}, nil
 	}
 	if p.match(NIL) {
-		return &Literal{nil}, nil
+		return &Literal{p.previous(), nil}, nil
 	}
 
 	if p.match(NUMBER, STRING) {
-		return &Literal{p.previous().literal}, nil
+		return &Literal{p.previous(), p.previous().literal}, nil
 	}
 
 	if p.match(SUPER) {
@@ -604,6 +792,19 @@ func (p *Parser) primary() (Expr, error) {
 		return &This{p.previous()}, nil
 	}
 
+	if p.match(FUN) {
+		return p.lambda()
+	}
+
+	if p.match(LEFT_BRACKET) {
+		return p.list()
+	}
+
+	// Blocks are statements, so a brace here can only start a map
+	if p.match(LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+
 	if p.match(IDENTIFIER) {
 		return &Variable{p.previous()}, nil
 	}
@@ -613,13 +814,73 @@ func (p *Parser) primary() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		p.consume(RIGHT_PAREN, "Expect ')' after expression.")
+		_, err = p.consume(RIGHT_PAREN, "Expect ')' after expression.")
+		if err != nil {
+			return nil, err
+		}
 		return &Grouping{expr}, nil
 	}
 
 	return nil, p.error(p.peek(), "Expect expression.")
 }
 
+// list parses the elements of a list literal, after the opening '['
+func (p *Parser) list() (Expr, error) {
+	var elements []Expr
+	if !p.check(RIGHT_BRACKET) {
+		// Mimic do-while loop
+		for {
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	bracket, err := p.consume(RIGHT_BRACKET, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+	return &List{bracket, elements}, nil
+}
+
+// mapLiteral parses the entries of a map literal, after the opening '{'
+func (p *Parser) mapLiteral() (Expr, error) {
+	var keys, values []Expr
+	if !p.check(RIGHT_BRACE) {
+		// Mimic do-while loop
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.consume(COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	brace, err := p.consume(RIGHT_BRACE, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+	return &Map{brace, keys, values}, nil
+}
+
 func (p *Parser) match(types ...TokenType) bool {
 	for _, l_type := range types {
 		if p.check(l_type) {
@@ -644,6 +905,14 @@ func (p *Parser) check(l_type TokenType) bool {
 	return p.peek().l_type == l_type
 }
 
+// checkNext looks one token past peek() without consuming anything
+func (p *Parser) checkNext(l_type TokenType) bool {
+	if p.isAtEnd() || p.tokens[p.current+1].l_type == EOF {
+		return false
+	}
+	return p.tokens[p.current+1].l_type == l_type
+}
+
 func (p *Parser) advance() Token {
 	if !p.isAtEnd() {
 		p.current += 1
@@ -664,8 +933,10 @@ func (p *Parser) previous() Token {
 }
 
 func (p *Parser) error(token Token, message string) error {
-	tokenError(token, message)
-	return ParseError{}
+	p.diagnostics.tokenError(ParseDiagnostic, token, message)
+	err := ParseError{token, message}
+	p.errors = append(p.errors, err)
+	return err
 }
 
 func (p *Parser) synchronize() {
@@ -675,7 +946,7 @@ func (p *Parser) synchronize() {
 			return
 		}
 		switch p.peek().l_type {
-		case CLASS, FOR, FUN, IF, PRINT, RETURN, VAR, WHILE:
+		case BREAK, CLASS, CONTINUE, FOR, FUN, IF, PRINT, RETURN, THROW, TRY, VAR, WHILE:
 			return
 		}
 		p.advance()
@@ -0,0 +1,301 @@
+package lox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chzyer/readline"
+)
+
+// replCommands are the REPL's meta-commands, which start with a colon
+var replCommands = []struct {
+	name, args, help string
+}{
+	{":help", "", "show this list"},
+	{":load", "<file>", "run a script, keeping what it defines"},
+	{":reset", "", "forget everything defined so far"},
+	{":env", "", "list the globals defined so far"},
+	{":ast", "<expr>", "print an expression's syntax tree"},
+	{":time", "<stmt>", "run input and print how long it took"},
+	{":quit", "", "leave the REPL"},
+}
+
+/*
+RunPrompt reads programs from stdin and runs them until it runs out of input.
+Input is collected until its brackets balance, so a function or class can be
+typed over several lines, and the value of a bare expression is printed.
+Everything defined stays available to later input.
+
+When stdin is a terminal, lines can be edited, earlier input is recalled with
+the arrow keys and saved to ~/.lox_history, and tab completes keywords and
+globals.
+*/
+func (l *Lox) RunPrompt() {
+	in := l.lineReader()
+	defer in.close()
+	if _, ok := in.(terminalReader); ok && l.stdin == l.promptInput {
+		// The line editor reads the terminal all the time, so programs reading it too have to go through it
+		l.interpreter.stdin.lines = in
+		defer func() { l.interpreter.stdin.lines = nil }()
+	}
+
+	var input strings.Builder
+	for {
+		prompt := "> "
+		if input.Len() > 0 {
+			prompt = "... "
+		}
+		line, err := in.readLine(prompt)
+		if errors.Is(err, readline.ErrInterrupt) {
+			// Ctrl-C throws away what's been typed
+			input.Reset()
+			continue
+		} else if err != nil {
+			return
+		}
+
+		if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if quit := l.command(strings.TrimSpace(line)); quit {
+				return
+			}
+			continue
+		}
+
+		if input.Len() > 0 {
+			input.WriteByte('\n')
+		}
+		input.WriteString(line)
+		if incomplete(input.String()) {
+			continue
+		}
+		l.runInput(input.String())
+		input.Reset()
+	}
+}
+
+// command runs a REPL meta-command, returning true if the REPL should stop
+func (l *Lox) command(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":help":
+		for _, c := range replCommands {
+			fmt.Fprintf(l.stdout, "%-14s %s\n", c.name+" "+c.args, c.help)
+		}
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(l.stderr, "Usage: :load <file>")
+			break
+		}
+		err := l.RunFile(arg)
+		var diagnostics *Diagnostics
+		if err != nil && !errors.As(err, &diagnostics) {
+			// Lox errors have already been reported
+			fmt.Fprintln(l.stderr, err)
+		}
+	case ":reset":
+		l.reset()
+	case ":env":
+		for _, name := range l.globalNames(false) {
+			fmt.Fprintf(l.stdout, "%s = %s\n", name, stringify(l.interpreter.globals.values[name]))
+		}
+	case ":ast":
+		diagnostics := NewDiagnostics(l.stderr)
+		tokens := NewScanner(arg).ScanTokens(diagnostics)
+		expr, _ := NewParser(tokens, diagnostics).parseExpression()
+		if !diagnostics.HadError() {
+			fmt.Fprintln(l.stdout, expr)
+		}
+	case ":time":
+		start := time.Now()
+		l.runInput(arg)
+		fmt.Fprintf(l.stdout, "Took %s.\n", time.Since(start))
+	case ":quit":
+		return true
+	default:
+		fmt.Fprintf(l.stderr, "Unknown command '%s'. Type :help for a list.\n", name)
+	}
+	return false
+}
+
+// runInput runs one complete piece of REPL input, echoing it if it's an expression
+func (l *Lox) runInput(source string) {
+	if !isExpression(source) {
+		l.Run(source)
+		return
+	}
+	value, err := l.Eval(source)
+	if err == nil {
+		fmt.Fprintln(l.stdout, stringify(value))
+	}
+}
+
+// globalNames lists the defined globals in order, leaving out the built-in ones unless asked for them
+func (l *Lox) globalNames(builtins bool) []string {
+	var names []string
+	for name := range l.interpreter.globals.values {
+		if builtins || !l.builtins[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// incomplete reports whether source has a bracket, string or comment still open
+func incomplete(source string) bool {
+	diagnostics := NewDiagnostics(io.Discard)
+	depth := 0
+	for _, token := range NewScanner(source).ScanTokens(diagnostics) {
+		switch token.l_type {
+		case LEFT_PAREN, LEFT_BRACE, LEFT_BRACKET:
+			depth++
+		case RIGHT_PAREN, RIGHT_BRACE, RIGHT_BRACKET:
+			depth--
+		}
+	}
+	for _, d := range diagnostics.All() {
+		if strings.HasPrefix(d.Message, "Unterminated") {
+			return true
+		}
+	}
+	return depth > 0
+}
+
+// isExpression reports whether source is a single expression with nothing after it
+func isExpression(source string) bool {
+	diagnostics := NewDiagnostics(io.Discard)
+	tokens := NewScanner(source).ScanTokens(diagnostics)
+	if diagnostics.HadError() {
+		return false
+	}
+	_, err := NewParser(tokens, diagnostics).parseExpression()
+	return err == nil && !diagnostics.HadError()
+}
+
+// lineReader reads the REPL's input a line at a time, showing a prompt first
+type lineReader interface {
+	readLine(prompt string) (string, error)
+	close() error
+}
+
+// lineReader edits lines in the terminal when stdin is one, and otherwise just reads them
+func (l *Lox) lineReader() lineReader {
+	if f, ok := l.promptInput.(*os.File); ok && readline.IsTerminal(int(f.Fd())) {
+		// Use the terminal we were given, which needn't be os.Stdin
+		fd := int(f.Fd())
+		var state *readline.State
+		config := &readline.Config{
+			AutoComplete: completer{l},
+			// Closing the line editor closes its stdin, which mustn't close f
+			Stdin:          readline.NewCancelableStdin(f),
+			Stdout:         l.stdout,
+			Stderr:         l.stderr,
+			FuncIsTerminal: func() bool { return true },
+			FuncMakeRaw: func() (err error) {
+				state, err = readline.MakeRaw(fd)
+				return err
+			},
+			FuncExitRaw: func() error {
+				if state == nil {
+					return nil
+				}
+				return readline.Restore(fd, state)
+			},
+			FuncGetWidth: func() int {
+				width, _, err := readline.GetSize(fd)
+				if err != nil {
+					return readline.GetScreenWidth()
+				}
+				return width
+			},
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			config.HistoryFile = filepath.Join(home, ".lox_history")
+		}
+		if rl, err := readline.NewEx(config); err == nil {
+			return terminalReader{rl}
+		}
+	}
+	return scannerReader{bufio.NewScanner(l.promptInput), l.stdout}
+}
+
+type terminalReader struct {
+	rl *readline.Instance
+}
+
+func (t terminalReader) readLine(prompt string) (string, error) {
+	t.rl.SetPrompt(prompt)
+	return t.rl.Readline()
+}
+
+func (t terminalReader) close() error {
+	return t.rl.Close()
+}
+
+type scannerReader struct {
+	s      *bufio.Scanner
+	prompt io.Writer
+}
+
+func (s scannerReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(s.prompt, prompt)
+	// Handles Ctrl-D for us
+	if !s.s.Scan() {
+		if err := s.s.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.s.Text(), nil
+}
+
+func (s scannerReader) close() error {
+	return nil
+}
+
+// completer completes meta-commands, keywords and globals for readline
+type completer struct {
+	l *Lox
+}
+
+func (c completer) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+	prefix := string(line[start:pos])
+
+	var candidates []string
+	if start == 1 && line[0] == ':' {
+		prefix = ":" + prefix
+		for _, command := range replCommands {
+			candidates = append(candidates, command.name)
+		}
+	} else if prefix != "" {
+		for keyword := range NewScanner("").keywords {
+			candidates = append(candidates, keyword)
+		}
+		candidates = append(candidates, c.l.globalNames(true)...)
+	}
+	sort.Strings(candidates)
+
+	var completions [][]rune
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			completions = append(completions, []rune(candidate[len(prefix):]))
+		}
+	}
+	return completions, len([]rune(prefix))
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
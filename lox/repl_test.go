@@ -0,0 +1,227 @@
+package lox
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPromptBuffersUntilBracketsBalance(t *testing.T) {
+	input := `class Greeter {
+  hi(name) {
+    print "hi " + name;
+  }
+}
+Greeter().hi(
+  "there");
+`
+	for _, backend := range backends {
+		var stdout, stderr bytes.Buffer
+		New(append(backend.options, WithStdout(&stdout), WithStderr(&stderr), WithPromptInput(strings.NewReader(input)))...).RunPrompt()
+
+		if got, expected := stdout.String(), "> ... ... ... ... > ... hi there\n> "; got != expected {
+			t.Errorf("%s: stdout was %q, expected %q", backend.name, got, expected)
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("%s: stderr was %q", backend.name, stderr.String())
+		}
+	}
+}
+
+func TestPromptEchoesExpressions(t *testing.T) {
+	input := "1 + 2\nvar a = \"x\";\na + \"y\"\nprint a;\nnil\n"
+	for _, backend := range backends {
+		var stdout bytes.Buffer
+		New(append(backend.options, WithStdout(&stdout), WithPromptInput(strings.NewReader(input)))...).RunPrompt()
+
+		if got, expected := stdout.String(), "> 3\n> > xy\n> x\n> nil\n> "; got != expected {
+			t.Errorf("%s: stdout was %q, expected %q", backend.name, got, expected)
+		}
+	}
+}
+
+// Closures made on one line keep their resolved locals when called on later ones
+func TestPromptKeepsDefinitions(t *testing.T) {
+	input := `fun counter() {
+  var n = 0;
+  fun count() {
+    n = n + 1;
+    return n;
+  }
+  return count;
+}
+var c = counter();
+c();
+undefined;
+c()
+`
+	for _, backend := range backends {
+		var stdout, stderr bytes.Buffer
+		New(append(backend.options, WithStdout(&stdout), WithStderr(&stderr), WithPromptInput(strings.NewReader(input)))...).RunPrompt()
+
+		if got, expected := stdout.String(), "> ... ... ... ... ... ... ... > > > > 2\n> "; got != expected {
+			t.Errorf("%s: stdout was %q, expected %q", backend.name, got, expected)
+		}
+		if !strings.HasPrefix(stderr.String(), "Undefined variable 'undefined'.") {
+			t.Errorf("%s: stderr was %q", backend.name, stderr.String())
+		}
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := map[string]bool{
+		"print 1;":            false,
+		"fun f() {":           true,
+		"f(1,":                true,
+		"[1, 2":               true,
+		"print \"a":           true,
+		"/* comment":          true,
+		"print \"{\";":        false,
+		"// {":                false,
+		"}":                   false,
+		"{ print (1 + 2); }":  false,
+		"{ if (true) { print": true,
+	}
+	for source, expected := range tests {
+		if got := incomplete(source); got != expected {
+			t.Errorf("incomplete(%q) was %v, expected %v", source, got, expected)
+		}
+	}
+}
+
+func TestPromptCommands(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.lox")
+	if err := os.WriteFile(script, []byte("var loaded = \"yes\";\nfun twice(x) { return x * 2; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		":load " + script,
+		":env",
+		":ast -twice(1 + 2)",
+		":ast 1 +",
+		":reset",
+		":env",
+		"loaded",
+		":load " + filepath.Join(dir, "missing.lox"),
+		":nope",
+		":quit",
+		"print \"not run\";",
+	}, "\n")
+	var stdout, stderr bytes.Buffer
+	New(WithStdout(&stdout), WithStderr(&stderr), WithPromptInput(strings.NewReader(input))).RunPrompt()
+
+	expected := "> > loaded = yes\ntwice = <fn twice>\n> (- (call twice (+ 1 2)))\n> > > > > > > "
+	if got := stdout.String(); got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+	for _, message := range []string{
+		"Expect expression.",
+		"Undefined variable 'loaded'.",
+		"missing.lox",
+		"Unknown command ':nope'. Type :help for a list.",
+	} {
+		if !strings.Contains(stderr.String(), message) {
+			t.Errorf("stderr was %q, expected it to contain %q", stderr.String(), message)
+		}
+	}
+}
+
+func TestPromptHelpAndTime(t *testing.T) {
+	var stdout bytes.Buffer
+	New(WithStdout(&stdout), WithPromptInput(strings.NewReader(":help\n:time print 1 + 1;\n"))).RunPrompt()
+
+	for _, c := range replCommands {
+		if !strings.Contains(stdout.String(), c.name) {
+			t.Errorf(":help didn't mention %s: %q", c.name, stdout.String())
+		}
+	}
+	if !regexp.MustCompile(`> 2\nTook [0-9.]+.?s\.\n> $`).MatchString(stdout.String()) {
+		t.Errorf(":time printed %q", stdout.String())
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	l := New()
+	l.Run("var counter = 1; fun count() {}")
+	c := completer{l}
+
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"cou", []string{"nt", "nter"}},
+		{"print wh", []string{"ile"}},
+		{"cl", []string{"ass", "ock"}},
+		{":l", []string{"oad"}},
+		{"x + ", nil},
+	}
+	for _, test := range tests {
+		completions, length := c.Do([]rune(test.line), len(test.line))
+		var got []string
+		for _, completion := range completions {
+			got = append(got, string(completion))
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("completing %q gave %q, expected %q", test.line, got, test.expected)
+		}
+		if word := test.line[strings.LastIndex(test.line, " ")+1:]; length != len(word) {
+			t.Errorf("completing %q replaced %d characters, expected %d", test.line, length, len(word))
+		}
+	}
+}
+
+// Programs run from the prompt read their own input, not the lines typed after them
+func TestPromptInputIsSeparateFromReadLine(t *testing.T) {
+	prompt := "var name = readLine();\nprint \"hi \" + name;\nprint readAll();\n"
+	for _, backend := range backends {
+		var stdout, stderr bytes.Buffer
+		New(append(backend.options, WithStdout(&stdout), WithStderr(&stderr),
+			WithPromptInput(strings.NewReader(prompt)), WithStdin(strings.NewReader("Ada\nthe rest\n")))...).RunPrompt()
+
+		if got, expected := stdout.String(), "> > hi Ada\n> the rest\n\n> "; got != expected {
+			t.Errorf("%s: stdout was %q, expected %q", backend.name, got, expected)
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("%s: stderr was %q", backend.name, stderr.String())
+		}
+	}
+}
+
+// fakeLines stands in for the terminal's line editor
+type fakeLines struct {
+	lines []string
+}
+
+func (f *fakeLines) readLine(prompt string) (string, error) {
+	if len(f.lines) == 0 {
+		return "", io.EOF
+	}
+	line := f.lines[0]
+	f.lines = f.lines[1:]
+	return line, nil
+}
+
+func (f *fakeLines) close() error {
+	return nil
+}
+
+// While the line editor owns the terminal, programs read through it rather than from stdin
+func TestInputGoesThroughLineEditor(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New(WithStdout(&stdout), WithStdin(strings.NewReader("from stdin\n")))
+	l.interpreter.stdin.lines = &fakeLines{[]string{"first", "second", "third"}}
+
+	if err := l.Run("print readLine(); print readAll(); print readLine();"); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	if got, expected := stdout.String(), "first\nsecond\nthird\n\nnil\n"; got != expected {
+		t.Errorf("stdout was %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,482 @@
+package lox
+
+import (
+	"strings"
+
+	"github.com/charlesdunbar/lox-go/classtype"
+	"github.com/charlesdunbar/lox-go/functiontype"
+)
+
+/*
+Resolver works out which declaration each variable reference refers to and
+records it in the interpreter. All of its state is its own, so separate
+Resolvers can run at the same time as long as they target different
+interpreters.
+*/
+type Resolver struct {
+	interpreter     *interpreter
+	scopes          []map[string]*scopeVariable
+	currentFunction functiontype.FunctionType
+	currentClass    classtype.ClassType
+	loopDepth       int
+	diagnostics     *Diagnostics
+	// When set, every variable reference is collected in references, for --resolve
+	recordReferences bool
+	references       []Expr
+	// Globals declared so far, which locals shouldn't shadow
+	globals map[string]bool
+}
+
+// NewResolver returns a Resolver that records what it finds in i and reports problems to diagnostics
+func NewResolver(i *interpreter, diagnostics *Diagnostics) *Resolver {
+	return &Resolver{
+		interpreter:     i,
+		scopes:          make([]map[string]*scopeVariable, 0),
+		currentFunction: functiontype.NONE,
+		currentClass:    classtype.NONE,
+		diagnostics:     diagnostics,
+	}
+}
+
+// scopeVariable is a local variable declared in one of the resolver's scopes
+type scopeVariable struct {
+	// Where the interpreter will find it in its environment
+	slot    int
+	defined bool
+	// Variables that are never read get a warning when their scope ends
+	name      Token
+	used      bool
+	parameter bool
+}
+
+func (r *Resolver) stmt_resolve(stmt Stmt) error {
+	switch t := stmt.(type) {
+	case *Block:
+		r.beginScope()
+		err := r.resolve_stmts(t.statements)
+		if err != nil {
+			return err
+		}
+		r.endScope()
+	case *Class:
+		enclosingClass := r.currentClass
+		r.currentClass = classtype.CLASS
+		r.declare(t.name)
+		r.define(t.name)
+		if t.superclass != (Variable{}) && t.name.lexeme == t.superclass.name.lexeme {
+			r.error(t.superclass.name, "A class can't inherit from itself.")
+		}
+
+		if t.superclass != (Variable{}) {
+			r.currentClass = classtype.SUBCLASS
+			err := r.expr_resolve(&t.superclass)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Deal with super keyword
+		if t.superclass != (Variable{}) {
+			r.beginScope()
+			front := r.scopes[len(r.scopes)-1]
+			front["super"] = &scopeVariable{slot: 0, defined: true, used: true}
+		}
+
+		r.beginScope()
+		front := r.scopes[len(r.scopes)-1]
+		front["this"] = &scopeVariable{slot: 0, defined: true, used: true}
+		for _, method := range t.methods {
+			declaration := functiontype.METHOD
+			if method.name.lexeme == "init" {
+				declaration = functiontype.INITIALIZER
+			}
+			err := r.resolveFunction(method, declaration)
+			if err != nil {
+				return err
+			}
+		}
+		r.endScope()
+
+		if t.superclass != (Variable{}) {
+			r.endScope()
+		}
+
+		r.currentClass = enclosingClass
+	case *Break:
+		if r.loopDepth == 0 {
+			r.error(t.keyword, "Can't use 'break' outside of a loop.")
+		}
+	case *Continue:
+		if r.loopDepth == 0 {
+			r.error(t.keyword, "Can't use 'continue' outside of a loop.")
+		}
+	case *Expression:
+		err := r.expr_resolve(t.expression)
+		if err != nil {
+			return nil
+		}
+	case *Function:
+		r.declare(t.name)
+		r.define(t.name)
+		err := r.resolveFunction(*t, functiontype.FUNCTION)
+		if err != nil {
+			return err
+		}
+	case *If:
+		err := r.expr_resolve(t.condition)
+		if err != nil {
+			return nil
+		}
+
+		err = r.stmt_resolve(t.thenBranch)
+		if err != nil {
+			return nil
+		}
+		if t.elseBranch != nil {
+			err = r.stmt_resolve(t.elseBranch)
+			if err != nil {
+				return nil
+			}
+		}
+	case *Print:
+		err := r.expr_resolve(t.expression)
+		if err != nil {
+			return nil
+		}
+	case *Return:
+		if r.currentFunction == functiontype.NONE {
+			r.error(t.keyword, "Can't return from top-level code.")
+		}
+		if t.value != nil {
+			if r.currentFunction == functiontype.INITIALIZER {
+				r.error(t.keyword, "Can't return a value from an initializer.")
+			}
+			err := r.expr_resolve(t.value)
+			if err != nil {
+				return nil
+			}
+		}
+	case *Throw:
+		err := r.expr_resolve(t.value)
+		if err != nil {
+			return err
+		}
+	case *Try:
+		// Each branch gets its own scope, matching the interpreter's environments
+		r.beginScope()
+		err := r.resolve_stmts(t.tryBranch)
+		r.endScope()
+		if err != nil {
+			return err
+		}
+		if t.catchName.lexeme != "" {
+			r.beginScope()
+			r.declare(t.catchName)
+			r.define(t.catchName)
+			err = r.resolve_stmts(t.catchBranch)
+			r.endScope()
+			if err != nil {
+				return err
+			}
+		}
+		r.beginScope()
+		err = r.resolve_stmts(t.finallyBranch)
+		r.endScope()
+		if err != nil {
+			return err
+		}
+	case *Var:
+		r.declare(t.name)
+		if t.initializer != nil {
+			err := r.expr_resolve(t.initializer)
+			if err != nil {
+				return nil
+			}
+		}
+		r.define(t.name)
+	case *While:
+		err := r.expr_resolve(t.condition)
+		if err != nil {
+			return nil
+		}
+
+		r.loopDepth++
+		err = r.stmt_resolve(t.body)
+		r.loopDepth--
+		if err != nil {
+			return nil
+		}
+
+		if t.increment != nil {
+			err = r.expr_resolve(t.increment)
+			if err != nil {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) expr_resolve(expr Expr) error {
+	switch t := expr.(type) {
+	case *Assign:
+		err := r.expr_resolve(t.value)
+		if err != nil {
+			return err
+		}
+		r.resolveLocal(t, t.name)
+	case *Binary:
+		err := r.expr_resolve(t.left)
+		if err != nil {
+			return nil
+		}
+		err = r.expr_resolve(t.right)
+		if err != nil {
+			return nil
+		}
+	case *Call:
+		err := r.expr_resolve(t.callee)
+		if err != nil {
+			return err
+		}
+		for _, arg := range t.arguments {
+			err := r.expr_resolve(arg)
+			if err != nil {
+				return nil
+			}
+		}
+	case *Get:
+		err := r.expr_resolve(t.object)
+		if err != nil {
+			return err
+		}
+	case *Grouping:
+		err := r.expr_resolve(t.expression)
+		if err != nil {
+			return nil
+		}
+	case *Index:
+		err := r.expr_resolve(t.object)
+		if err != nil {
+			return err
+		}
+		err = r.expr_resolve(t.index)
+		if err != nil {
+			return err
+		}
+	case *Lambda:
+		err := r.resolveFunction(Function{t.keyword, t.params, t.body}, functiontype.FUNCTION)
+		if err != nil {
+			return err
+		}
+	case *List:
+		for _, element := range t.elements {
+			err := r.expr_resolve(element)
+			if err != nil {
+				return err
+			}
+		}
+	case *Literal:
+		return nil
+	case *Map:
+		for n := range t.keys {
+			err := r.expr_resolve(t.keys[n])
+			if err != nil {
+				return err
+			}
+			err = r.expr_resolve(t.values[n])
+			if err != nil {
+				return err
+			}
+		}
+	case *Logical:
+		err := r.expr_resolve(t.left)
+		if err != nil {
+			return nil
+		}
+		err = r.expr_resolve(t.right)
+		if err != nil {
+			return nil
+		}
+	case *Set:
+		err := r.expr_resolve(t.value)
+		if err != nil {
+			return nil
+		}
+		err = r.expr_resolve(t.object)
+		if err != nil {
+			return nil
+		}
+	case *SetIndex:
+		err := r.expr_resolve(t.value)
+		if err != nil {
+			return err
+		}
+		err = r.expr_resolve(t.object)
+		if err != nil {
+			return err
+		}
+		err = r.expr_resolve(t.index)
+		if err != nil {
+			return err
+		}
+	case *Super:
+		if r.currentClass == classtype.NONE {
+			r.error(t.keyword, "Can't use 'super' outside of a class.")
+		} else if r.currentClass != classtype.SUBCLASS {
+			r.error(t.keyword, "Can't use 'super' in a class with no superclass.")
+		}
+		r.resolveLocal(t, t.keyword)
+	case *This:
+		if r.currentClass == classtype.NONE {
+			r.error(t.keyword, "Can't use 'this' outside of a class.")
+		}
+		r.resolveLocal(t, t.keyword)
+	case *Unary:
+		err := r.expr_resolve(t.right)
+		if err != nil {
+			return nil
+		}
+	case *Variable:
+		if len(r.scopes) != 0 {
+			front := r.scopes[len(r.scopes)-1]
+			// Declared in this scope but not yet defined means we're in its initializer
+			if v, ok := front[t.name.lexeme]; ok {
+				if !v.defined {
+					r.error(t.name, "Can't read local variable in its own initializer.")
+				}
+			}
+		}
+		if v := r.resolveLocal(t, t.name); v != nil {
+			v.used = true
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) resolve_stmts(stmts []Stmt) error {
+	for _, stmt := range stmts {
+		err := r.stmt_resolve(stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) resolveFunction(function Function, t functiontype.FunctionType) error {
+	enclosingFunction := r.currentFunction
+	enclosingLoopDepth := r.loopDepth
+	r.currentFunction = t
+	// A loop outside the function can't be broken out of from inside it
+	r.loopDepth = 0
+	r.beginScope()
+	for _, param := range function.params {
+		if v := r.declare(param); v != nil {
+			v.parameter = true
+		}
+		r.define(param)
+	}
+	err := r.resolve_stmts(function.body)
+	if err != nil {
+		return err
+	}
+	r.endScope()
+	r.currentFunction = enclosingFunction
+	r.loopDepth = enclosingLoopDepth
+	return nil
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, map[string]*scopeVariable{})
+}
+
+func (r *Resolver) endScope() {
+	r.warnUnused(r.scopes[len(r.scopes)-1])
+	r.scopes = r.scopes[:len(r.scopes)-1] // Pop a slice
+}
+
+// declare adds name to the innermost scope, returning the new local if there is one
+func (r *Resolver) declare(name Token) *scopeVariable {
+	if len(r.scopes) == 0 {
+		if r.globals == nil {
+			r.globals = make(map[string]bool)
+		}
+		r.globals[name.lexeme] = true
+		return nil
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.lexeme]; ok {
+		r.error(name, "Already a variable with this name in this scope.")
+		return nil
+	}
+	r.warnShadowing(name)
+	// Variables are defined at runtime in the order they're declared, so that's their slot
+	v := &scopeVariable{slot: len(scope), name: name}
+	scope[name.lexeme] = v
+	return v
+}
+
+// Names starting with an underscore are never warned about
+func silenced(name Token) bool {
+	return strings.HasPrefix(name.lexeme, "_")
+}
+
+// warnShadowing warns when a new local hides a variable from an enclosing scope or a global
+func (r *Resolver) warnShadowing(name Token) {
+	if silenced(name) {
+		return
+	}
+	for i := len(r.scopes) - 2; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.lexeme]; ok {
+			r.diagnostics.warning(name, "Shadows a local variable in an enclosing scope.")
+			return
+		}
+	}
+	_, defined := r.interpreter.globals.values[name.lexeme]
+	if r.globals[name.lexeme] || defined {
+		r.diagnostics.warning(name, "Shadows a global variable.")
+	}
+}
+
+// warnUnused warns about every variable in scope that was never read
+func (r *Resolver) warnUnused(scope map[string]*scopeVariable) {
+	for _, v := range scope {
+		if v.used || silenced(v.name) {
+			continue
+		}
+		if v.parameter {
+			r.diagnostics.warning(v.name, "Parameter is never used.")
+		} else {
+			r.diagnostics.warning(v.name, "Local variable is never used.")
+		}
+	}
+}
+
+func (r *Resolver) define(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	scope[name.lexeme].defined = true
+
+}
+
+func (r *Resolver) error(token Token, message string) {
+	r.diagnostics.tokenError(ResolveDiagnostic, token, message)
+}
+
+// Stores the environment distance away from the expression, returning the local it refers to if there is one
+func (r *Resolver) resolveLocal(expr Expr, name Token) *scopeVariable {
+	if r.recordReferences {
+		r.references = append(r.references, expr)
+	}
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		scope := r.scopes[i]
+		if v, ok := scope[name.lexeme]; ok {
+			r.interpreter.resolve(expr, len(r.scopes)-1-i, v.slot)
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package lox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestResolverWarnings(t *testing.T) {
+	tests := []struct {
+		source   string
+		warnings []string
+	}{
+		{"{ var a = 1; print a; }", nil},
+		{"{ var a = 1; }", []string{"[line 1] Warning at 'a': Local variable is never used."}},
+		// Assigning isn't using
+		{"{ var a; a = 1; }", []string{"[line 1] Warning at 'a': Local variable is never used."}},
+		{"fun f(a, b) { return a; }", []string{"[line 1] Warning at 'b': Parameter is never used."}},
+		{"var a = 1; fun f() { var a = 2; print a; }", []string{"[line 1] Warning at 'a': Shadows a global variable."}},
+		{"fun f(x) { { var x = 1; print x; } print x; }", []string{"[line 1] Warning at 'x': Shadows a local variable in an enclosing scope."}},
+		{"{ fun helper() {} class Unused {} }", []string{
+			"[line 1] Warning at 'helper': Local variable is never used.",
+			"[line 1] Warning at 'Unused': Local variable is never used.",
+		}},
+		{"try { throw 1; } catch (e) { print 1; }", []string{"[line 1] Warning at 'e': Local variable is never used."}},
+		// A leading underscore says it's deliberate
+		{"var _a = 1; fun f(_b) { var _a = 2; { var _unused; } }", nil},
+		// Globals are never unused, and this and super aren't variables
+		{"var a; class A { m() { return this; } } class B < A { m() { return super.m(); } }", nil},
+		// Built-in globals can be shadowed too
+		{"fun f() { var len = 1; print len; }", []string{"[line 1] Warning at 'len': Shadows a global variable."}},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		err := New(WithStdout(&stdout), WithStderr(&stderr)).Run(test.source)
+		if err != nil {
+			t.Errorf("%s: warnings should not stop the program, got %v", test.source, err)
+			continue
+		}
+		var warnings []string
+		for _, line := range splitLines(stderr.String()) {
+			if reportedWarningPattern.MatchString(line) {
+				warnings = append(warnings, line)
+			}
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: warnings were\n%q\nexpected\n%q", test.source, warnings, test.warnings)
+		}
+	}
+}
+
+// Globals from earlier runs count, as they do in the REPL
+func TestShadowingGlobalsFromEarlierRuns(t *testing.T) {
+	var stderr bytes.Buffer
+	l := New(WithStderr(&stderr))
+	l.Run("var count = 0;")
+	l.Run("fun f() { var count = 1; return count; }")
+
+	if got, expected := splitLines(stderr.String()), "[line 1] Warning at 'count': Shadows a global variable."; len(got) == 0 || got[0] != expected {
+		t.Errorf("stderr was %q, expected %q first", got, expected)
+	}
+}
+
+func TestWarningsAreDiagnostics(t *testing.T) {
+	d := NewDiagnostics(&bytes.Buffer{})
+	statements := New().parse("{ var a; }", d)
+	resolver := NewResolver(NewInterpreter(), d)
+	resolver.resolve_stmts(statements)
+	d.flushWarnings()
+
+	if d.HadError() {
+		t.Errorf("a warning set HadError")
+	}
+	all := d.All()
+	if len(all) != 1 || all[0].Kind != WarningDiagnostic || all[0].Lexeme != "a" {
+		t.Errorf("got diagnostics %v, expected one warning about a", all)
+	}
+}
+
+// resolveSource resolves source with a Resolver of its own, returning what it reported.
+// It's safe to call from any goroutine.
+func resolveSource(source string) ([]Diagnostic, error) {
+	d := NewDiagnostics(io.Discard)
+	statements := New(WithStderr(io.Discard)).parse(source, d)
+	if d.HadError() {
+		return nil, fmt.Errorf("%q didn't parse: %v", source, d)
+	}
+	NewResolver(NewInterpreter(), d).resolve_stmts(statements)
+	return d.All(), nil
+}
+
+func TestNestedClasses(t *testing.T) {
+	source := `class Base { m() { return 1; } }
+class Outer {
+  method() {
+    class Inner < Base {
+      m() { return super.m(); }
+    }
+    print Inner().m();
+    print super.m;
+    return this;
+  }
+}`
+	diagnostics, err := resolveSource(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Line != 8 || diagnostics[0].Message != "Can't use 'super' in a class with no superclass." {
+		t.Errorf("got %v, expected only the super in Outer to be reported", diagnostics)
+	}
+}
+
+// With class state shared between resolvers, a class being resolved in one would let another use this or super outside one
+func TestConcurrentResolution(t *testing.T) {
+	programs := []struct {
+		source string
+		errors int
+	}{
+		{"class A { m() { return this; } } class B < A { m() { return super.m(); } }", 0},
+		{"print this;", 1},
+		{"fun f() { return super.x; }", 1},
+		{"class A { init() { return; } } class B < A { init() { super.init(); print this; } }", 0},
+	}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		for _, program := range programs {
+			program := program
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					diagnostics, err := resolveSource(program.source)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					var errors int
+					for _, d := range diagnostics {
+						if d.Kind == ResolveDiagnostic {
+							errors++
+						}
+					}
+					if errors != program.errors {
+						t.Errorf("%q: got %d errors, expected %d", program.source, errors, program.errors)
+						return
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// Each Lox has its own interpreter, so whole programs can run at the same time too
+func TestConcurrentRuns(t *testing.T) {
+	source := `class Counter {
+  init() { this.n = 0; }
+  add() { var next = this.n + 1; this.n = next; return this; }
+}
+var c = Counter();
+for (var i = 0; i < 100; i = i + 1) c.add();
+print c.n;`
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		backend := backends[n%len(backends)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var stdout, stderr bytes.Buffer
+			l := New(append(backend.options, WithStdout(&stdout), WithStderr(&stderr))...)
+			if err := l.Run(source); err != nil {
+				t.Errorf("%s: %v", backend.name, err)
+			}
+			if got := stdout.String(); got != "100\n" {
+				t.Errorf("%s: printed %q, expected 100", backend.name, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,4 +1,4 @@
-package main
+package lox
 
 type ReturnError struct {
 	value any
@@ -0,0 +1,79 @@
+package lox
+
+import "fmt"
+
+type RuntimeError struct {
+	token Token
+	err   string
+	// Set when the error came from a throw statement rather than the interpreter
+	thrown bool
+	value  any
+	// Filled in as the error unwinds out of its innermost call
+	trace []StackFrame
+}
+
+func NewRuntimeError(token Token, message string) *RuntimeError {
+	return &RuntimeError{
+		token: token,
+		err:   message,
+	}
+}
+
+// NewThrowError wraps a value thrown by Lox code. If nothing catches it, it's reported like any other runtime error.
+func NewThrowError(keyword Token, value any) *RuntimeError {
+	return &RuntimeError{
+		token:  keyword,
+		err:    stringify(value),
+		thrown: true,
+		value:  value,
+	}
+}
+
+// loxValue is what a catch block sees: the thrown value, or an error object for the interpreter's own errors
+func (e *RuntimeError) loxValue() any {
+	if e.thrown {
+		return e.value
+	}
+	return NewLoxError(e.err, e.token.line)
+}
+
+func (e RuntimeError) Error() string {
+	return formatTraceback(e.err, e.token, e.trace)
+}
+
+/*
+formatTraceback prints a runtime error's message, then its traceback innermost
+frame first, with the line that failed shown under the first frame:
+
+	operand must be a number.
+	[line 2] in negate()
+	   2 |   return -n;
+	     |          ^
+	[line 5] in script
+*/
+func formatTraceback(message string, token Token, trace []StackFrame) string {
+	s := message
+	if len(trace) == 0 {
+		trace = []StackFrame{{Line: token.line}}
+	}
+	repeats := 0
+	for n, frame := range trace {
+		// Deep recursion would otherwise bury the rest of the trace
+		if n > 0 && frame == trace[n-1] {
+			repeats++
+			if repeats >= maxRepeatedFrames {
+				if n+1 == len(trace) || trace[n+1] != frame {
+					s += fmt.Sprintf("\n[previous frame repeated %d more times]", repeats-maxRepeatedFrames+1)
+				}
+				continue
+			}
+		} else {
+			repeats = 0
+		}
+		s += "\n" + frame.String()
+		if excerpt := token.excerpt(); n == 0 && excerpt != "" {
+			s += "\n" + excerpt
+		}
+	}
+	return s
+}
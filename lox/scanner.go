@@ -1,56 +1,71 @@
-package main
+package lox
 
 import (
 	"strconv"
 )
 
 type Scanner struct {
-	source   string
-	tokens   []Token
-	start    int
-	current  int
-	line     int
-	keywords map[string]TokenType
+	source    string
+	tokens    []Token
+	start     int
+	current   int
+	line      int
+	lineStart int
+	// Where the current token starts, which can be lines before current for a multi-line string
+	startLine int
+	column    int
+	keywords  map[string]TokenType
 }
 
 func NewScanner(source string) *Scanner {
 	return &Scanner{
-		source:  source,
-		tokens:  []Token{},
-		start:   0,
-		current: 0,
-		line:    1,
+		source:    source,
+		tokens:    []Token{},
+		start:     0,
+		current:   0,
+		line:      1,
+		startLine: 1,
+		column:    1,
 		keywords: map[string]TokenType{
-			"and":    AND,
-			"class":  CLASS,
-			"else":   ELSE,
-			"false":  FALSE,
-			"for":    FOR,
-			"fun":    FUN,
-			"if":     IF,
-			"nil":    NIL,
-			"or":     OR,
-			"print":  PRINT,
-			"return": RETURN,
-			"super":  SUPER,
-			"this":   THIS,
-			"true":   TRUE,
-			"var":    VAR,
-			"while":  WHILE,
+			"and":      AND,
+			"break":    BREAK,
+			"catch":    CATCH,
+			"class":    CLASS,
+			"continue": CONTINUE,
+			"else":     ELSE,
+			"false":    FALSE,
+			"finally":  FINALLY,
+			"for":      FOR,
+			"fun":      FUN,
+			"if":       IF,
+			"nil":      NIL,
+			"or":       OR,
+			"print":    PRINT,
+			"return":   RETURN,
+			"super":    SUPER,
+			"this":     THIS,
+			"throw":    THROW,
+			"true":     TRUE,
+			"try":      TRY,
+			"var":      VAR,
+			"while":    WHILE,
 		},
 	}
 }
 
-func (s *Scanner) ScanTokens(l *Lox) []Token {
+func (s *Scanner) ScanTokens(d *Diagnostics) []Token {
 	for !s.isAtEnd() {
 		s.start = s.current
-		s.scanToken(l)
+		s.startLine, s.column = s.line, s.start-s.lineStart+1
+		s.scanToken(d)
 	}
-	s.tokens = append(s.tokens, Token{EOF, "", nil, s.line})
+	s.start = s.current
+	s.startLine, s.column = s.line, s.start-s.lineStart+1
+	s.addToken(EOF)
 	return s.tokens
 }
 
-func (s *Scanner) scanToken(l *Lox) {
+func (s *Scanner) scanToken(d *Diagnostics) {
 	c := s.advance()
 	switch c {
 	case '(':
@@ -61,6 +76,12 @@ func (s *Scanner) scanToken(l *Lox) {
 		s.addToken(LEFT_BRACE)
 	case '}':
 		s.addToken(RIGHT_BRACE)
+	case '[':
+		s.addToken(LEFT_BRACKET)
+	case ']':
+		s.addToken(RIGHT_BRACKET)
+	case ':':
+		s.addToken(COLON)
 	case ',':
 		s.addToken(COMMA)
 	case '.':
@@ -103,7 +124,7 @@ func (s *Scanner) scanToken(l *Lox) {
 				s.advance()
 			}
 		} else if s.match('*') {
-			s.multiComment(l)
+			s.multiComment(d)
 
 		} else {
 			s.addToken(SLASH)
@@ -112,10 +133,10 @@ func (s *Scanner) scanToken(l *Lox) {
 	case '\r':
 	case '\t':
 	case '\n':
-		s.line++
+		s.newLine()
 
 	case '"':
-		s.string(l)
+		s.string(d)
 
 	default:
 		if s.isDigit(c) {
@@ -123,7 +144,7 @@ func (s *Scanner) scanToken(l *Lox) {
 		} else if s.isAlpha(c) {
 			s.identifier()
 		} else {
-			lox_error(s.line, "Unexpected character.")
+			d.scanError(s.token(ERROR, nil), "Unexpected character.")
 		}
 	}
 }
@@ -190,16 +211,18 @@ func (s *Scanner) isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
-func (s *Scanner) string(l *Lox) {
+func (s *Scanner) string(d *Diagnostics) {
 	for s.peek() != '"' && !s.isAtEnd() {
 		if s.peek() == '\n' {
-			s.line++
+			s.advance()
+			s.newLine()
+			continue
 		}
 		s.advance()
 	}
 
 	if s.isAtEnd() {
-		lox_error(s.line, "Unterminated string.")
+		d.scanError(s.token(ERROR, nil), "Unterminated string.")
 		return
 	}
 
@@ -210,16 +233,18 @@ func (s *Scanner) string(l *Lox) {
 	s.addToken(STRING, value)
 }
 
-func (s *Scanner) multiComment(l *Lox) {
+func (s *Scanner) multiComment(d *Diagnostics) {
 	for !(s.peek() == '*' && s.peekNext() == '/') && !s.isAtEnd() {
 		if s.peek() == '\n' {
-			s.line++
+			s.advance()
+			s.newLine()
+			continue
 		}
 		s.advance()
 	}
 
 	if s.isAtEnd() {
-		lox_error(s.line, "Unterminated multiline comment.")
+		d.scanError(s.token(ERROR, nil), "Unterminated multiline comment.")
 		return
 	}
 
@@ -227,6 +252,12 @@ func (s *Scanner) multiComment(l *Lox) {
 	s.advance() // The closing /.
 }
 
+// newLine records that the byte just consumed was a newline
+func (s *Scanner) newLine() {
+	s.line++
+	s.lineStart = s.current
+}
+
 func (s *Scanner) match(expected byte) bool {
 	if s.isAtEnd() {
 		return false
@@ -280,6 +311,19 @@ func (s *Scanner) addToken(l_type TokenType, literal ...any) {
 }
 
 func (s *Scanner) addTokenTypeObject(l_type TokenType, literal any) {
-	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, Token{l_type, text, literal, s.line})
+	s.tokens = append(s.tokens, s.token(l_type, literal))
+}
+
+// token builds a token for the lexeme between start and current
+func (s *Scanner) token(l_type TokenType, literal any) Token {
+	return Token{
+		l_type:  l_type,
+		lexeme:  s.source[s.start:s.current],
+		literal: literal,
+		line:    s.startLine,
+		column:  s.column,
+		start:   s.start,
+		length:  s.current - s.start,
+		source:  &s.source,
+	}
 }
@@ -1,6 +1,9 @@
-package main
+package lox
 
-import "testing"
+import (
+	"io"
+	"testing"
+)
 
 func TestPeek(t *testing.T) {
 	s := NewScanner("testing peek")
@@ -50,7 +53,7 @@ func TestAddTokenTypeObject(t *testing.T) {
 	s := NewScanner("64")
 	s.current = 2
 	s.addTokenTypeObject(NUMBER, 64.0)
-	expected := Token{NUMBER, "64", 64.0, 1}
+	expected := Token{NUMBER, "64", 64.0, 1, 1, 0, 2, &s.source}
 	if s.tokens[0] != expected {
 		t.Errorf("AddTokenTypeObject was incorrect, got %+v, expected {%+v}", s.tokens[0], expected)
 	}
@@ -60,16 +63,16 @@ func TestAddToken(t *testing.T) {
 	s := NewScanner("+")
 	s.current = 1
 	s.addToken(PLUS)
-	expected := Token{PLUS, "+", nil, 1}
+	expected := Token{PLUS, "+", nil, 1, 1, 0, 1, &s.source}
 	if s.tokens[0] != expected {
 		t.Errorf("AddToken was incorrect, got %+v, expected %+v", s.tokens[0], expected)
 	}
 
 	s = NewScanner("64")
 	s.current = 2
-	s.line = 5
+	s.startLine = 5
 	s.addToken(NUMBER, 64.0)
-	expected = Token{NUMBER, "64", 64.0, 5}
+	expected = Token{NUMBER, "64", 64.0, 5, 1, 0, 2, &s.source}
 	if s.tokens[0] != expected {
 		t.Errorf("AddTokenTypeObject was incorrect, got %+v, expected %+v", s.tokens[0], expected)
 	}
@@ -89,3 +92,48 @@ func TestMatch(t *testing.T) {
 		t.Errorf("Match was incorrect, got %t, expected %t", got, false)
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	d := NewDiagnostics(io.Discard)
+	s := NewScanner("var a;\n  \"two\nlines\" b\n\tc")
+	tokens := s.ScanTokens(d)
+
+	expected := []struct {
+		lexeme                      string
+		line, column, start, length int
+	}{
+		{"var", 1, 1, 0, 3},
+		{"a", 1, 5, 4, 1},
+		{";", 1, 6, 5, 1},
+		// A string over several lines is where it starts, not where it ends
+		{"\"two\nlines\"", 2, 3, 9, 11},
+		{"b", 3, 8, 21, 1},
+		{"c", 4, 2, 24, 1},
+		{"", 4, 3, 25, 0},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("got %d tokens, expected %d", len(tokens), len(expected))
+	}
+	for i, e := range expected {
+		got := tokens[i]
+		if got.lexeme != e.lexeme || got.line != e.line || got.column != e.column || got.start != e.start || got.length != e.length {
+			t.Errorf("token %d was %q at line %d, column %d, offset %d, length %d; expected %q at line %d, column %d, offset %d, length %d",
+				i, got.lexeme, got.line, got.column, got.start, got.length, e.lexeme, e.line, e.column, e.start, e.length)
+		}
+	}
+}
+
+func TestTokenExcerpt(t *testing.T) {
+	d := NewDiagnostics(io.Discard)
+	tokens := NewScanner("{\n\tprint \"hi\" + x;\n}\n").ScanTokens(d)
+
+	str := tokens[2]
+	if got, expected := str.excerpt(), "   2 | \tprint \"hi\" + x;\n     | \t      ^^^^"; got != expected {
+		t.Errorf("excerpt was\n%s\nexpected\n%s", got, expected)
+	}
+
+	eof := tokens[len(tokens)-1]
+	if got, expected := eof.excerpt(), "   3 | }\n     |  ^"; got != expected {
+		t.Errorf("EOF excerpt was\n%s\nexpected\n%s", got, expected)
+	}
+}
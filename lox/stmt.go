@@ -1,4 +1,4 @@
-package main
+package lox
 
 type Stmt interface {
 	Statement() Stmt
@@ -8,12 +8,20 @@ type Block struct {
 	statements []Stmt
 }
 
+type Break struct {
+	keyword Token
+}
+
 type Class struct {
 	name       Token
 	superclass Variable
 	methods    []Function
 }
 
+type Continue struct {
+	keyword Token
+}
+
 type Expression struct {
 	expression Expr
 }
@@ -25,6 +33,7 @@ type Function struct {
 }
 
 type If struct {
+	keyword    Token
 	condition  Expr
 	thenBranch Stmt
 	elseBranch Stmt
@@ -36,6 +45,7 @@ type Var struct {
 }
 
 type Print struct {
+	keyword    Token
 	expression Expr
 }
 
@@ -44,15 +54,33 @@ type Return struct {
 	value   Expr
 }
 
+type Throw struct {
+	keyword Token
+	value   Expr
+}
+
+type Try struct {
+	keyword       Token
+	tryBranch     []Stmt
+	catchName     Token
+	catchBranch   []Stmt
+	finallyBranch []Stmt
+}
+
 type While struct {
 	condition Expr
 	body      Stmt
+	increment Expr
 }
 
 func (e *Block) Statement() Stmt { return e }
 
+func (e *Break) Statement() Stmt { return e }
+
 func (e *Class) Statement() Stmt { return e }
 
+func (e *Continue) Statement() Stmt { return e }
+
 func (e *Expression) Statement() Stmt { return e }
 
 func (e *Function) Statement() Stmt { return e }
@@ -65,4 +93,8 @@ func (e *Print) Statement() Stmt { return e }
 
 func (e *Return) Statement() Stmt { return e }
 
+func (e *Throw) Statement() Stmt { return e }
+
+func (e *Try) Statement() Stmt { return e }
+
 func (e *While) Statement() Stmt { return e }
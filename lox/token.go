@@ -0,0 +1,75 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Token struct {
+	l_type  TokenType
+	lexeme  string
+	literal any
+	line    int
+	// column is 1-based, start and length are byte offsets into source
+	column int
+	start  int
+	length int
+	source *string
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%s, %s, %+v", t.l_type, t.lexeme, t.literal)
+}
+
+/*
+excerpt returns the line of source the token is on, with the token
+underlined by carets:
+
+	3 | print a +;
+	  |          ^
+
+It returns "" for tokens that weren't scanned from source.
+*/
+func (t Token) excerpt() string {
+	if t.source == nil || t.start > len(*t.source) {
+		return ""
+	}
+	src := *t.source
+	start := t.start
+	if t.l_type == EOF {
+		// Point just past the last real character instead of at a blank line
+		start = len(strings.TrimRight(src, " \t\r\n"))
+	}
+
+	lineStart := strings.LastIndexByte(src[:start], '\n') + 1
+	lineEnd := strings.IndexByte(src[start:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(src)
+	} else {
+		lineEnd += start
+	}
+	lineNumber := strings.Count(src[:lineStart], "\n") + 1
+
+	// Keep tabs in the padding so the carets line up with the token
+	var pad strings.Builder
+	for _, c := range []byte(src[lineStart:start]) {
+		if c == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	width := t.length
+	if start+width > lineEnd {
+		width = lineEnd - start
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	return fmt.Sprintf("%4d | %s\n     | %s%s",
+		lineNumber,
+		strings.TrimRight(src[lineStart:lineEnd], "\r"),
+		pad.String(),
+		strings.Repeat("^", width))
+}
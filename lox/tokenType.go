@@ -1,4 +1,4 @@
-package main
+package lox
 
 //go:generate stringer -type=TokenType
 type TokenType int
@@ -9,6 +9,9 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
+	COLON
 	COMMA
 	DOT
 	MINUS
@@ -34,9 +37,13 @@ const (
 
 	//Keywords.
 	AND
+	BREAK
+	CATCH
 	CLASS
+	CONTINUE
 	ELSE
 	FALSE
+	FINALLY
 	FUN
 	FOR
 	IF
@@ -46,9 +53,13 @@ const (
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
 
+	// Marks the text of a scan error; never added to the token list.
+	ERROR
 	EOF
 )
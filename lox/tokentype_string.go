@@ -0,0 +1,72 @@
+// Code generated by "stringer -type=TokenType"; DO NOT EDIT.
+
+package lox
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[LEFT_PAREN-0]
+	_ = x[RIGHT_PAREN-1]
+	_ = x[LEFT_BRACE-2]
+	_ = x[RIGHT_BRACE-3]
+	_ = x[LEFT_BRACKET-4]
+	_ = x[RIGHT_BRACKET-5]
+	_ = x[COLON-6]
+	_ = x[COMMA-7]
+	_ = x[DOT-8]
+	_ = x[MINUS-9]
+	_ = x[PLUS-10]
+	_ = x[SEMICOLON-11]
+	_ = x[SLASH-12]
+	_ = x[STAR-13]
+	_ = x[BANG-14]
+	_ = x[BANG_EQUAL-15]
+	_ = x[EQUAL-16]
+	_ = x[EQUAL_EQUAL-17]
+	_ = x[GREATER-18]
+	_ = x[GREATER_EQUAL-19]
+	_ = x[LESS-20]
+	_ = x[LESS_EQUAL-21]
+	_ = x[IDENTIFIER-22]
+	_ = x[STRING-23]
+	_ = x[NUMBER-24]
+	_ = x[AND-25]
+	_ = x[BREAK-26]
+	_ = x[CATCH-27]
+	_ = x[CLASS-28]
+	_ = x[CONTINUE-29]
+	_ = x[ELSE-30]
+	_ = x[FALSE-31]
+	_ = x[FINALLY-32]
+	_ = x[FUN-33]
+	_ = x[FOR-34]
+	_ = x[IF-35]
+	_ = x[NIL-36]
+	_ = x[OR-37]
+	_ = x[PRINT-38]
+	_ = x[RETURN-39]
+	_ = x[SUPER-40]
+	_ = x[THIS-41]
+	_ = x[THROW-42]
+	_ = x[TRUE-43]
+	_ = x[TRY-44]
+	_ = x[VAR-45]
+	_ = x[WHILE-46]
+	_ = x[ERROR-47]
+	_ = x[EOF-48]
+}
+
+const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACELEFT_BRACKETRIGHT_BRACKETCOLONCOMMADOTMINUSPLUSSEMICOLONSLASHSTARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTIFIERSTRINGNUMBERANDBREAKCATCHCLASSCONTINUEELSEFALSEFINALLYFUNFORIFNILORPRINTRETURNSUPERTHISTHROWTRUETRYVARWHILEERROREOF"
+
+var _TokenType_index = [...]uint16{0, 10, 21, 31, 42, 54, 67, 72, 77, 80, 85, 89, 98, 103, 107, 111, 121, 126, 137, 144, 157, 161, 171, 181, 187, 193, 196, 201, 206, 211, 219, 223, 228, 235, 238, 241, 243, 246, 248, 253, 259, 264, 268, 273, 277, 280, 283, 288, 293, 296}
+
+func (i TokenType) String() string {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_TokenType_index)-1 {
+		return "TokenType(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _TokenType_name[_TokenType_index[idx]:_TokenType_index[idx+1]]
+}
@@ -0,0 +1,499 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+)
+
+type vmFrame struct {
+	closure *vmClosure
+	ip      int
+	// Where the frame's slot zero is on the stack
+	slots int
+	// Set when an initializer is running because its class was called, so the traceback names the class
+	constructor bool
+}
+
+// vmHandler is where to go if an error is raised inside a try statement
+type vmHandler struct {
+	frames int
+	stack  int
+	target int
+}
+
+/*
+vm runs compiled functions on a value stack. It uses the same values as
+the tree-walking interpreter wherever it can, so natives, lists, maps and
+errors behave the same whichever backend runs a program.
+*/
+type vm struct {
+	frames       []vmFrame
+	stack        []any
+	handlers     []vmHandler
+	openUpvalues *vmUpvalue
+	globals      map[string]any
+	stdout       io.Writer
+}
+
+func newVM(globals map[string]any, stdout io.Writer) *vm {
+	return &vm{
+		globals: globals,
+		stdout:  stdout,
+	}
+}
+
+// interpret runs a compiled script and returns the value it returned
+func (vm *vm) interpret(script *vmFunction) (any, error) {
+	closure := &vmClosure{function: script}
+	vm.push(closure)
+	vm.frames = append(vm.frames, vmFrame{closure: closure})
+	value, err := vm.run()
+	if err != nil {
+		// Leave the VM ready for the prompt's next line
+		vm.frames = vm.frames[:0]
+		vm.stack = vm.stack[:0]
+		vm.handlers = vm.handlers[:0]
+		vm.openUpvalues = nil
+	}
+	return value, err
+}
+
+func (vm *vm) run() (any, error) {
+	frame := &vm.frames[len(vm.frames)-1]
+	for {
+		var err error
+		code := frame.closure.function.chunk.code
+		op := OpCode(code[frame.ip])
+		frame.ip++
+
+		switch op {
+		case OP_CONSTANT:
+			vm.push(frame.closure.function.chunk.constants[vm.readShort(frame)])
+		case OP_NIL:
+			vm.push(nil)
+		case OP_TRUE:
+			vm.push(true)
+		case OP_FALSE:
+			vm.push(false)
+		case OP_POP:
+			vm.pop()
+		case OP_GET_LOCAL:
+			vm.push(vm.stack[frame.slots+vm.readShort(frame)])
+		case OP_SET_LOCAL:
+			vm.stack[frame.slots+vm.readShort(frame)] = vm.peek(0)
+		case OP_GET_GLOBAL:
+			name := vm.readString(frame)
+			value, ok := vm.globals[name]
+			if ok {
+				vm.push(value)
+			} else {
+				err = vm.error("Undefined variable '" + name + "'.")
+			}
+		case OP_DEFINE_GLOBAL:
+			vm.globals[vm.readString(frame)] = vm.pop()
+		case OP_SET_GLOBAL:
+			name := vm.readString(frame)
+			if _, ok := vm.globals[name]; ok {
+				vm.globals[name] = vm.peek(0)
+			} else {
+				err = vm.error("Undefined variable '" + name + "'.")
+			}
+		case OP_GET_UPVALUE:
+			upvalue := frame.closure.upvalues[vm.readShort(frame)]
+			if upvalue.open {
+				vm.push(vm.stack[upvalue.slot])
+			} else {
+				vm.push(upvalue.closed)
+			}
+		case OP_SET_UPVALUE:
+			upvalue := frame.closure.upvalues[vm.readShort(frame)]
+			if upvalue.open {
+				vm.stack[upvalue.slot] = vm.peek(0)
+			} else {
+				upvalue.closed = vm.peek(0)
+			}
+		case OP_GET_PROPERTY:
+			err = vm.getProperty(vm.readString(frame))
+		case OP_SET_PROPERTY:
+			name := vm.readString(frame)
+			value := vm.pop()
+			if instance, ok := vm.pop().(*vmInstance); ok {
+				instance.fields[name] = value
+				vm.push(value)
+			} else {
+				err = vm.error("Only instances have fields.")
+			}
+		case OP_GET_SUPER:
+			name := vm.readString(frame)
+			superclass := vm.pop().(*vmClass)
+			receiver := vm.pop()
+			if method, ok := superclass.methods[name]; ok {
+				vm.push(&vmBoundMethod{receiver, method})
+			} else {
+				err = vm.error(fmt.Sprintf("Undefined property %s.", name))
+			}
+		case OP_GET_INDEX:
+			index := vm.pop()
+			var value any
+			switch object := vm.pop().(type) {
+			case *LoxList:
+				value, err = object.getIndex(vm.token(), index)
+			case *LoxMap:
+				value, err = object.getIndex(vm.token(), index)
+			default:
+				err = vm.error("Only lists and maps can be indexed.")
+			}
+			vm.push(value)
+		case OP_SET_INDEX:
+			value := vm.pop()
+			index := vm.pop()
+			switch object := vm.pop().(type) {
+			case *LoxList:
+				err = object.setIndex(vm.token(), index, value)
+			case *LoxMap:
+				err = object.setIndex(vm.token(), index, value)
+			default:
+				err = vm.error("Only lists and maps can be indexed.")
+			}
+			vm.push(value)
+		case OP_EQUAL:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(isEqual(a, b))
+		case OP_GREATER, OP_GREATER_EQUAL, OP_LESS, OP_LESS_EQUAL, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE:
+			err = vm.arithmetic(op)
+		case OP_ADD:
+			b := vm.pop()
+			a := vm.pop()
+			if l, ok := a.(float64); ok {
+				if r, ok := b.(float64); ok {
+					vm.push(l + r)
+					break
+				}
+			} else if l, ok := a.(string); ok {
+				if r, ok := b.(string); ok {
+					vm.push(l + r)
+					break
+				}
+			}
+			err = vm.error("operands must be two numbers or two strings.")
+		case OP_NOT:
+			vm.push(!isTruthy(vm.pop()))
+		case OP_NEGATE:
+			if n, ok := vm.peek(0).(float64); ok {
+				vm.stack[len(vm.stack)-1] = -n
+			} else {
+				err = vm.error("operand must be a number.")
+			}
+		case OP_PRINT:
+			fmt.Fprintln(vm.stdout, stringify(vm.pop()))
+		case OP_JUMP:
+			offset := vm.readShort(frame)
+			frame.ip += offset
+		case OP_JUMP_IF_FALSE:
+			offset := vm.readShort(frame)
+			if !isTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case OP_LOOP:
+			offset := vm.readShort(frame)
+			frame.ip -= offset
+		case OP_CALL:
+			argCount := vm.readShort(frame)
+			err = vm.callValue(vm.peek(argCount), argCount)
+			frame = &vm.frames[len(vm.frames)-1]
+		case OP_CLOSURE:
+			function := frame.closure.function.chunk.constants[vm.readShort(frame)].(*vmFunction)
+			closure := &vmClosure{function, make([]*vmUpvalue, function.upvalueCount)}
+			for n := range closure.upvalues {
+				isLocal := code[frame.ip] == 1
+				frame.ip++
+				index := vm.readShort(frame)
+				if isLocal {
+					closure.upvalues[n] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.upvalues[n] = frame.closure.upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+		case OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == 0 {
+				vm.stack = vm.stack[:0]
+				return result, nil
+			}
+			vm.stack = vm.stack[:frame.slots]
+			vm.push(result)
+			frame = &vm.frames[len(vm.frames)-1]
+		case OP_CLASS:
+			vm.push(&vmClass{vm.readString(frame), make(map[string]*vmClosure)})
+		case OP_INHERIT:
+			superclass, ok := vm.peek(1).(*vmClass)
+			if !ok {
+				err = vm.error("Superclass must be a class.")
+				break
+			}
+			subclass := vm.pop().(*vmClass)
+			for name, method := range superclass.methods {
+				subclass.methods[name] = method
+			}
+		case OP_METHOD:
+			method := vm.pop().(*vmClosure)
+			vm.peek(0).(*vmClass).methods[vm.readString(frame)] = method
+		case OP_LIST:
+			count := vm.readShort(frame)
+			elements := make([]any, count)
+			copy(elements, vm.stack[len(vm.stack)-count:])
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(NewLoxList(elements))
+		case OP_MAP:
+			count := vm.readShort(frame)
+			entries := vm.stack[len(vm.stack)-2*count:]
+			m := NewLoxMap()
+			for n := 0; n < count && err == nil; n++ {
+				err = m.setIndex(vm.token(), entries[2*n], entries[2*n+1])
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			vm.push(m)
+		case OP_THROW:
+			err = NewThrowError(vm.token(), vm.pop())
+		case OP_PUSH_HANDLER:
+			locals := vm.readShort(frame)
+			offset := vm.readShort(frame)
+			vm.handlers = append(vm.handlers, vmHandler{len(vm.frames), frame.slots + locals, frame.ip + offset})
+		case OP_POP_HANDLER:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case OP_RETHROW:
+			err = vm.pop().(*RuntimeError)
+		case OP_ERROR_VALUE:
+			vm.stack[len(vm.stack)-1] = vm.peek(0).(*RuntimeError).loxValue()
+		}
+
+		if err != nil {
+			if err = vm.raise(err); err != nil {
+				return nil, err
+			}
+			frame = &vm.frames[len(vm.frames)-1]
+		}
+	}
+}
+
+func (vm *vm) push(value any) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *vm) pop() any {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *vm) peek(distance int) any {
+	return vm.stack[len(vm.stack)-1-distance]
+}
+
+func (vm *vm) readShort(frame *vmFrame) int {
+	code := frame.closure.function.chunk.code
+	n := int(code[frame.ip])<<8 | int(code[frame.ip+1])
+	frame.ip += 2
+	return n
+}
+
+func (vm *vm) readString(frame *vmFrame) string {
+	return frame.closure.function.chunk.constants[vm.readShort(frame)].(string)
+}
+
+// token is where the instruction being run came from in the source
+func (vm *vm) token() Token {
+	frame := &vm.frames[len(vm.frames)-1]
+	return frame.closure.function.chunk.tokenAt(frame.ip - 1)
+}
+
+func (vm *vm) error(message string) error {
+	return NewRuntimeError(vm.token(), message)
+}
+
+func (vm *vm) arithmetic(op OpCode) error {
+	r, ok := vm.pop().(float64)
+	l, ok2 := vm.pop().(float64)
+	if !ok || !ok2 {
+		return vm.error("operands must be a number.")
+	}
+	switch op {
+	case OP_GREATER:
+		vm.push(l > r)
+	case OP_GREATER_EQUAL:
+		vm.push(l >= r)
+	case OP_LESS:
+		vm.push(l < r)
+	case OP_LESS_EQUAL:
+		vm.push(l <= r)
+	case OP_SUBTRACT:
+		vm.push(l - r)
+	case OP_MULTIPLY:
+		vm.push(l * r)
+	case OP_DIVIDE:
+		vm.push(l / r)
+	}
+	return nil
+}
+
+func (vm *vm) getProperty(name string) error {
+	var value any
+	var err error
+	switch object := vm.peek(0).(type) {
+	case *vmInstance:
+		if field, ok := object.fields[name]; ok {
+			value = field
+		} else if method, ok := object.class.methods[name]; ok {
+			value = &vmBoundMethod{object, method}
+		} else {
+			err = vm.error(fmt.Sprintf("Undefined property %s.", name))
+		}
+	case *LoxList:
+		value, err = object.get(vm.token())
+	case *LoxMap:
+		value, err = object.get(vm.token())
+	case *LoxError:
+		value, err = object.get(vm.token())
+	default:
+		err = vm.error("Only instances have properties.")
+	}
+	vm.stack[len(vm.stack)-1] = value
+	return err
+}
+
+func (vm *vm) callValue(callee any, argCount int) error {
+	switch c := callee.(type) {
+	case *vmClosure:
+		return vm.call(c, argCount, false)
+	case *vmBoundMethod:
+		vm.stack[len(vm.stack)-argCount-1] = c.receiver
+		return vm.call(c.method, argCount, false)
+	case *vmClass:
+		vm.stack[len(vm.stack)-argCount-1] = &vmInstance{c, make(map[string]any)}
+		if initializer, ok := c.methods["init"]; ok {
+			return vm.call(initializer, argCount, true)
+		}
+		if argCount != 0 {
+			return vm.error(fmt.Sprintf("Expected %d arguments but got %d.", 0, argCount))
+		}
+		return nil
+	case LoxCallable:
+		// Natives don't need an interpreter, which is what lets both backends share them
+		if c.arity() != variadic && argCount != c.arity() {
+			return vm.error(fmt.Sprintf("Expected %d arguments but got %d.", c.arity(), argCount))
+		}
+		args := make([]any, argCount)
+		copy(args, vm.stack[len(vm.stack)-argCount:])
+		result, err := c.call(nil, args)
+		if ne, ok := err.(*nativeError); ok {
+			return vm.error(ne.message)
+		}
+		if err != nil {
+			return err
+		}
+		vm.stack = vm.stack[:len(vm.stack)-argCount-1]
+		vm.push(result)
+		return nil
+	}
+	return vm.error("Can only call functions and classes.")
+}
+
+func (vm *vm) call(closure *vmClosure, argCount int, constructor bool) error {
+	if argCount != closure.function.arity {
+		return vm.error(fmt.Sprintf("Expected %d arguments but got %d.", closure.function.arity, argCount))
+	}
+	// The script's own frame doesn't count, to match the interpreter
+	if len(vm.frames) > maxFrames {
+		return vm.error("Stack overflow.")
+	}
+	vm.frames = append(vm.frames, vmFrame{
+		closure:     closure,
+		slots:       len(vm.stack) - argCount - 1,
+		constructor: constructor,
+	})
+	return nil
+}
+
+func (vm *vm) captureUpvalue(slot int) *vmUpvalue {
+	var previous *vmUpvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		previous = upvalue
+		upvalue = upvalue.next
+	}
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &vmUpvalue{slot: slot, open: true, next: upvalue}
+	if previous == nil {
+		vm.openUpvalues = created
+	} else {
+		previous.next = created
+	}
+	return created
+}
+
+// closeUpvalues moves variables in slots from last upwards off the stack and into their upvalues
+func (vm *vm) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.open = false
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+/*
+raise sends an error to the innermost handler, unwinding the stack to it.
+If nothing handles it, it's returned with its traceback, to end the program.
+*/
+func (vm *vm) raise(err error) error {
+	re, ok := err.(*RuntimeError)
+	if !ok {
+		return err
+	}
+	if re.trace == nil {
+		re.trace = vm.traceback(re)
+	}
+	if len(vm.handlers) == 0 {
+		return re
+	}
+
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.closeUpvalues(handler.stack)
+	vm.frames = vm.frames[:handler.frames]
+	vm.stack = vm.stack[:handler.stack]
+	vm.push(re)
+	vm.frames[len(vm.frames)-1].ip = handler.target
+	return nil
+}
+
+// traceback lists the frames an error was raised in, innermost first, like the interpreter's
+func (vm *vm) traceback(re *RuntimeError) []StackFrame {
+	var trace []StackFrame
+	line := re.token.line
+	for n := len(vm.frames) - 1; n >= 0; n-- {
+		frame := vm.frames[n]
+		name := ""
+		if n > 0 {
+			name = frame.closure.function.frameName()
+			if frame.constructor {
+				name = frame.closure.function.className
+			}
+		}
+		if n < len(vm.frames)-1 {
+			line = frame.closure.function.chunk.tokenAt(frame.ip - 1).line
+		}
+		trace = append(trace, StackFrame{name, line})
+	}
+	return trace
+}
@@ -0,0 +1,86 @@
+package lox
+
+import "fmt"
+
+// vmFunction is a function compiled to bytecode. The top level of a program is one too, with no name.
+type vmFunction struct {
+	name string
+	// Set for methods, so tracebacks can show which class they're on
+	className    string
+	arity        int
+	upvalueCount int
+	chunk        chunk
+	isScript     bool
+}
+
+func (f *vmFunction) frameName() string {
+	name := f.name
+	if name == "" {
+		name = "anonymous"
+	}
+	if f.className != "" {
+		return f.className + "." + name
+	}
+	return name
+}
+
+func (f *vmFunction) String() string {
+	if f.isScript {
+		return "<script>"
+	}
+	if f.name == "" {
+		return "<fn anonymous>"
+	}
+	return fmt.Sprintf("<fn %s>", f.name)
+}
+
+// vmClosure is a function value: a compiled function and the variables it captured
+type vmClosure struct {
+	function *vmFunction
+	upvalues []*vmUpvalue
+}
+
+func (c *vmClosure) String() string {
+	return c.function.String()
+}
+
+/*
+vmUpvalue is a captured variable. While the variable is still on the stack
+the upvalue refers to its slot; once that slot is popped the value moves
+into the upvalue itself.
+*/
+type vmUpvalue struct {
+	slot   int
+	open   bool
+	closed any
+	// Open upvalues form a list sorted by slot, highest first
+	next *vmUpvalue
+}
+
+type vmClass struct {
+	name string
+	// Includes inherited methods, which are copied down when the class is created
+	methods map[string]*vmClosure
+}
+
+func (c *vmClass) String() string {
+	return c.name
+}
+
+type vmInstance struct {
+	class  *vmClass
+	fields map[string]any
+}
+
+func (i *vmInstance) String() string {
+	return fmt.Sprintf("%s instance", i.class.name)
+}
+
+type vmBoundMethod struct {
+	receiver any
+	method   *vmClosure
+}
+
+func (b *vmBoundMethod) String() string {
+	return b.method.String()
+}
@@ -0,0 +1,58 @@
+package lox
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestVMRecoversBetweenRuns(t *testing.T) {
+	var stdout bytes.Buffer
+	l := New(WithBytecode(), WithStdout(&stdout), WithStderr(io.Discard))
+
+	if err := l.Run("var count = 1; fun bump() { count = count + 1; return count; }"); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	// An error deep in a call mustn't leave frames or values behind for the next run
+	if err := l.Run("fun fail(n) { if (n == 0) return nil + 1; return fail(n - 1); } try { fail(3); } finally { bump(); }"); err == nil {
+		t.Fatalf("expected a runtime error")
+	}
+	if err := l.Run("print bump();"); err != nil {
+		t.Fatalf("Run() returned %v", err)
+	}
+	got, err := l.Eval("count * 10")
+	if err != nil {
+		t.Fatalf("Eval() returned %v", err)
+	}
+	if got != 30.0 || stdout.String() != "3\n" {
+		t.Errorf("got %v and printed %q, expected 30 and \"3\\n\"", got, stdout.String())
+	}
+	if len(l.vm.frames) != 0 || len(l.vm.stack) != 0 || len(l.vm.handlers) != 0 {
+		t.Errorf("VM left %d frames, %d values and %d handlers", len(l.vm.frames), len(l.vm.stack), len(l.vm.handlers))
+	}
+}
+
+const fibProgram = `
+fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 2) + fib(n - 1);
+}
+fib(20);
+`
+
+func benchmarkFib(b *testing.B, options ...Option) {
+	l := New(options...)
+	for n := 0; n < b.N; n++ {
+		if err := l.Run(fibProgram); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFibInterpreter(b *testing.B) {
+	benchmarkFib(b)
+}
+
+func BenchmarkFibVM(b *testing.B) {
+	benchmarkFib(b, WithBytecode())
+}
@@ -1,21 +1,90 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+
+	"github.com/charlesdunbar/lox-go/lox"
 )
 
 func main() {
-	lox := NewLox()
-	cmdArgs := os.Args[1:]
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: lox [flags] [script]")
+		flag.PrintDefaults()
+	}
+	files := flag.String("files", "", "let scripts read and write files under `dir`")
+	bytecode := flag.Bool("vm", false, "compile to bytecode and run on the VM instead of the tree-walking interpreter")
+	tokens := flag.Bool("tokens", false, "print the script's tokens instead of running it")
+	ast := flag.Bool("ast", false, "print the script's syntax tree instead of running it")
+	resolve := flag.Bool("resolve", false, "print where each variable reference in the script resolves to instead of running it")
+	disassemble := flag.Bool("disassemble", false, "print the script's bytecode instead of running it")
+	exportJSON := flag.Bool("export-json", false, "print the script's syntax tree as JSON instead of running it")
+	importJSON := flag.Bool("import-json", false, "run a syntax tree written by -export-json instead of a script")
+	flag.Parse()
+
+	var options []lox.Option
+	if *bytecode {
+		options = append(options, lox.WithBytecode())
+	}
+	if *files != "" {
+		options = append(options, lox.WithFileAccess(*files))
+	}
+	l := lox.New(options...)
+	cmdArgs := flag.Args()
+
+	// Each of these shows one stage's output for a script
+	var dump func(string) error
+	switch {
+	case *tokens:
+		dump = l.DumpTokens
+	case *ast:
+		dump = l.DumpAST
+	case *resolve:
+		dump = l.DumpResolution
+	case *disassemble:
+		dump = l.Disassemble
+	case *exportJSON:
+		dump = func(source string) error {
+			data, err := l.ExportJSON(source)
+			if err == nil {
+				_, err = fmt.Printf("%s\n", data)
+			}
+			return err
+		}
+	}
 
-	if len(cmdArgs) == 0 {
-		//test()
-		lox.RunPrompt()
+	if len(cmdArgs) == 0 && dump == nil {
+		l.RunPrompt()
 	} else if len(cmdArgs) == 1 {
-		lox.RunFile(cmdArgs[0])
+		var err error
+		if dump != nil {
+			var source []byte
+			source, err = os.ReadFile(cmdArgs[0])
+			if err == nil {
+				err = dump(string(source))
+			}
+		} else if *importJSON {
+			var data []byte
+			data, err = os.ReadFile(cmdArgs[0])
+			if err == nil {
+				err = l.RunJSON(data)
+			}
+		} else {
+			err = l.RunFile(cmdArgs[0])
+		}
+		var runtimeErr *lox.RuntimeError
+		if errors.Is(err, lox.ErrCompile) {
+			os.Exit(65)
+		} else if errors.As(err, &runtimeErr) {
+			os.Exit(70)
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(66)
+		}
 	} else {
-		fmt.Println("Usage: lox [script]")
+		flag.Usage()
 		os.Exit(64)
 	}
 }
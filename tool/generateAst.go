@@ -24,9 +24,14 @@ func main() {
 		"Call	   : callee Expr, paren Token, arguments []Expr",
 		"Get       : object Expr, name Token",
 		"Grouping  : expression Expr",
-		"Literal   : value any",
+		"Index     : object Expr, bracket Token, index Expr",
+		"Lambda    : keyword Token, params []Token, body []Stmt",
+		"List      : bracket Token, elements []Expr",
+		"Literal   : token Token, value any",
 		"Logical   : left Expr, operator Token, right Expr",
+		"Map       : brace Token, keys []Expr, values []Expr",
 		"Set       : object Expr, name Token, value Expr",
+		"SetIndex  : object Expr, bracket Token, index Expr, value Expr",
 		"Super     : keyword Token, method Token",
 		"This      : keyword Token",
 		"Unary     : operator Token, right Expr",
@@ -35,14 +40,18 @@ func main() {
 
 	defineAst(outputDir, "Stmt", []string{
 		"Block      : statements []Stmt",
+		"Break      : keyword Token",
 		"Class		: name Token, superclass Variable, methods []Function",
+		"Continue   : keyword Token",
 		"Expression : expression Expr",
 		"Function   : name Token, params []Token, body []Stmt",
-		"If         : condition Expr, thenBranch Stmt, elseBranch Stmt",
+		"If         : keyword Token, condition Expr, thenBranch Stmt, elseBranch Stmt",
 		"Var        : name Token, initializer Expr",
-		"Print      : expression Expr",
+		"Print      : keyword Token, expression Expr",
 		"Return     : keyword Token, value Expr",
-		"While      : condition Expr, body Stmt",
+		"Throw      : keyword Token, value Expr",
+		"Try        : keyword Token, tryBranch []Stmt, catchName Token, catchBranch []Stmt, finallyBranch []Stmt",
+		"While      : condition Expr, body Stmt, increment Expr",
 	})
 }
 
@@ -56,7 +65,7 @@ func defineAst(outputDir string, baseName string, types []string) {
 	defer f.Close()
 	buf := new(bytes.Buffer)
 
-	buf.WriteString("package main\n\n")
+	buf.WriteString("package lox\n\n")
 	var fun string
 	if baseName == "Expr" {
 		fun = "Expression()"